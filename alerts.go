@@ -0,0 +1,423 @@
+package yfi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// AlertKind identifies the condition an Alert tests.
+type AlertKind string
+
+const (
+	// PriceAbove fires when RegularMarketPrice rises to or above Level.
+	PriceAbove AlertKind = "priceAbove"
+	// PriceBelow fires when RegularMarketPrice falls to or below Level.
+	PriceBelow AlertKind = "priceBelow"
+	// PercentChange fires when the absolute RegularMarketChangePercent reaches Level.
+	PercentChange AlertKind = "percentChange"
+	// VolumeSpike fires when RegularMarketVolume reaches Level times AverageDailyVolume10Day.
+	VolumeSpike AlertKind = "volumeSpike"
+	// NewFiftyTwoWeekHigh fires when RegularMarketPrice exceeds FiftyTwoWeekHigh.
+	NewFiftyTwoWeekHigh AlertKind = "fiftyTwoWeekHigh"
+)
+
+// Alert is a condition on a symbol's Quote. An Alert fires when its condition becomes true and is
+// re-armed once the condition is false again, so a condition that stays true is reported only once.
+// Cooldown is the minimum time between two firings of the same Alert.
+type Alert struct {
+	Id       string        `json:"id"`
+	Symbol   string        `json:"symbol"`
+	Kind     AlertKind     `json:"kind"`
+	Level    float64       `json:"level"`
+	Cooldown time.Duration `json:"cooldown"`
+}
+
+// check reports whether the condition of a holds for q, along with the value that was tested.
+func (a Alert) check(q Quote) (bool, float64, error) {
+	switch a.Kind {
+	case PriceAbove:
+		return q.RegularMarketPrice >= a.Level, q.RegularMarketPrice, nil
+	case PriceBelow:
+		return q.RegularMarketPrice > 0 && q.RegularMarketPrice <= a.Level, q.RegularMarketPrice, nil
+	case PercentChange:
+		p := q.RegularMarketChangePercent
+		return p >= a.Level || -p >= a.Level, p, nil
+	case VolumeSpike:
+		if q.AverageDailyVolume10Day <= 0 {
+			return false, 0, nil
+		}
+		ratio := float64(q.RegularMarketVolume) / float64(q.AverageDailyVolume10Day)
+		return ratio >= a.Level, ratio, nil
+	case NewFiftyTwoWeekHigh:
+		return q.FiftyTwoWeekHigh > 0 && q.RegularMarketPrice > q.FiftyTwoWeekHigh, q.RegularMarketPrice, nil
+	}
+	return false, 0, ErrAlertKind
+}
+
+// AlertEvent is sent to Notifiers when an Alert fires. Value is the price, percent change
+// or volume ratio that triggered the Alert.
+type AlertEvent struct {
+	Alert   Alert     `json:"alert"`
+	Value   float64   `json:"value"`
+	Time    time.Time `json:"time"`
+	Message string    `json:"message"`
+	Quote   Quote     `json:"quote"`
+}
+
+func (a Alert) message(v float64) string {
+	f := func(x float64) string { return strconv.FormatFloat(x, 'f', -1, 64) }
+	switch a.Kind {
+	case PriceAbove:
+		return a.Symbol + " price " + f(v) + " crossed above " + f(a.Level)
+	case PriceBelow:
+		return a.Symbol + " price " + f(v) + " crossed below " + f(a.Level)
+	case PercentChange:
+		return a.Symbol + " changed " + strconv.FormatFloat(v, 'f', 2, 64) + "%"
+	case VolumeSpike:
+		return a.Symbol + " volume is " + strconv.FormatFloat(v, 'f', 2, 64) + "x its 10 day average"
+	case NewFiftyTwoWeekHigh:
+		return a.Symbol + " made a new 52-week high at " + f(v)
+	}
+	return a.Symbol + " " + string(a.Kind)
+}
+
+// Notifier delivers AlertEvents.
+type Notifier interface {
+	Notify(AlertEvent) error
+}
+
+// NotifierFunc adapts a function to the Notifier interface.
+type NotifierFunc func(AlertEvent) error
+
+func (f NotifierFunc) Notify(ev AlertEvent) error {
+	return f(ev)
+}
+
+// WriterNotifier writes the message of each event on its own line, prefixed by the event time.
+type WriterNotifier struct {
+	W io.Writer
+}
+
+// StdoutNotifier returns a WriterNotifier that writes to os.Stdout.
+func StdoutNotifier() WriterNotifier {
+	return WriterNotifier{W: os.Stdout}
+}
+
+func (n WriterNotifier) Notify(ev AlertEvent) error {
+	_, err := fmt.Fprintln(n.W, ev.Time.Format(time.RFC3339), ev.Message)
+	return err
+}
+
+// FileNotifier appends each event to the file at Path as a line of JSON.
+type FileNotifier struct {
+	Path string
+}
+
+func (n FileNotifier) Notify(ev AlertEvent) error {
+	b, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(n.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(append(b, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// WebhookNotifier POSTs each event to URL as a JSON object.
+type WebhookNotifier struct {
+	URL    string
+	Client *http.Client
+}
+
+func (n WebhookNotifier) Notify(ev AlertEvent) error {
+	b, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	hc := n.Client
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err := hc.Post(n.URL, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.New("webhook error " + resp.Status)
+	}
+	return nil
+}
+
+type alertState struct {
+	Active    bool      `json:"active"`
+	LastFired time.Time `json:"lastFired"`
+}
+
+type alertFile struct {
+	Alerts []Alert                `json:"alerts"`
+	State  map[string]*alertState `json:"state"`
+}
+
+// AlertEngine evaluates Alerts against Quotes and dispatches the resulting events to its Notifiers.
+// If StatePath is set, the alerts and the state used for cooldowns and deduplication are saved
+// to that file whenever they change, so that they survive restarts.
+type AlertEngine struct {
+	Notifiers []Notifier
+	StatePath string
+	// OnError, if not nil, is called with every notification or persistence error.
+	OnError func(error)
+	Verbose bool
+
+	mu     sync.Mutex
+	alerts map[string]Alert
+	state  map[string]*alertState
+	last   map[string]Quote // the last Quote checked for each symbol, onto which ticks are merged
+}
+
+// NewAlertEngine returns an AlertEngine that dispatches to notifiers. If the file at statePath exists,
+// the alerts and state saved in it are restored. An empty statePath disables persistence.
+func NewAlertEngine(statePath string, notifiers ...Notifier) (*AlertEngine, error) {
+	e := &AlertEngine{
+		Notifiers: notifiers,
+		StatePath: statePath,
+		alerts:    make(map[string]Alert),
+		state:     make(map[string]*alertState),
+		last:      make(map[string]Quote),
+	}
+	if statePath == "" {
+		return e, nil
+	}
+	b, err := os.ReadFile(statePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return e, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var f alertFile
+	if err = json.Unmarshal(b, &f); err != nil {
+		return nil, err
+	}
+	for _, a := range f.Alerts {
+		e.alerts[a.Id] = a
+	}
+	for id, s := range f.State {
+		if _, ok := e.alerts[id]; ok && s != nil {
+			e.state[id] = s
+		}
+	}
+	return e, nil
+}
+
+// Add adds alerts, replacing any existing alerts with the same Id. Alerts without an Id are assigned one.
+func (e *AlertEngine) Add(alerts ...Alert) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, a := range alerts {
+		if _, _, err := a.check(Quote{}); err != nil {
+			return err
+		}
+		if a.Id == "" {
+			a.Id = a.Symbol + "-" + string(a.Kind) + "-" + strconv.FormatFloat(a.Level, 'f', -1, 64)
+		}
+		if old, ok := e.alerts[a.Id]; ok && old != a {
+			delete(e.state, a.Id)
+		}
+		e.alerts[a.Id] = a
+	}
+	return e.save()
+}
+
+// Remove removes the alerts with the given ids.
+func (e *AlertEngine) Remove(ids ...string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, id := range ids {
+		delete(e.alerts, id)
+		delete(e.state, id)
+	}
+	return e.save()
+}
+
+// Alerts returns the registered alerts sorted by Id.
+func (e *AlertEngine) Alerts() []Alert {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	res := make([]Alert, 0, len(e.alerts))
+	for _, a := range e.alerts {
+		res = append(res, a)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Id < res[j].Id })
+	return res
+}
+
+// Check evaluates the alerts on q.Symbol at time now, notifies every Notifier of the alerts that fire
+// and returns the corresponding events.
+func (e *AlertEngine) Check(q Quote, now time.Time) []AlertEvent {
+	var events []AlertEvent
+	e.mu.Lock()
+	e.last[q.Symbol] = q
+	changed := false
+	for id, a := range e.alerts {
+		if a.Symbol != q.Symbol {
+			continue
+		}
+		ok, v, _ := a.check(q)
+		s := e.state[id]
+		if s == nil {
+			s = &alertState{}
+			e.state[id] = s
+		}
+		if !ok {
+			if s.Active {
+				s.Active = false
+				changed = true
+			}
+			continue
+		}
+		if s.Active || (!s.LastFired.IsZero() && now.Sub(s.LastFired) < a.Cooldown) {
+			continue
+		}
+		s.Active = true
+		s.LastFired = now
+		changed = true
+		events = append(events, AlertEvent{Alert: a, Value: v, Time: now, Message: a.message(v), Quote: q})
+	}
+	var err error
+	if changed {
+		err = e.save()
+	}
+	e.mu.Unlock()
+	if err != nil {
+		e.reportErr(err)
+	}
+
+	sort.Slice(events, func(i, j int) bool { return events[i].Alert.Id < events[j].Alert.Id })
+	for _, ev := range events {
+		for _, n := range e.Notifiers {
+			if err := n.Notify(ev); err != nil {
+				e.reportErr(err)
+			}
+		}
+	}
+	return events
+}
+
+// Watch checks the alerts against every Quote polled by w, including the first Quote of each symbol,
+// so that conditions that already hold when watching starts are reported. The returned function stops watching.
+func (e *AlertEngine) Watch(w *Watcher) func() {
+	return w.OnQuote(func(q Quote, t time.Time) {
+		e.Check(q, t)
+	})
+}
+
+// mergeTick returns q updated with the fields that are set in t. Ticks reported outside of regular
+// market hours update the post-market fields, as Yahoo does for Quotes.
+func mergeTick(q Quote, t Tick) Quote {
+	q.Symbol = t.Symbol
+	q.MarketState = t.MarketHours.MarketState()
+	if t.MarketHours == RegularMarket {
+		if t.Price != 0 {
+			q.RegularMarketPrice = t.Price
+			q.RegularMarketChange = t.Change
+			q.RegularMarketChangePercent = t.ChangePercent
+		}
+		if !t.Time.IsZero() {
+			q.RegularMarketTime = int(t.Time.Unix())
+		}
+	} else if t.Price != 0 {
+		q.PostMarketPrice = t.Price
+		q.PostMarketChange = t.Change
+		q.PostMarketChangePercent = t.ChangePercent
+		if !t.Time.IsZero() {
+			q.PostMarketTime = int(t.Time.Unix())
+		}
+	}
+	if t.DayVolume != 0 {
+		q.RegularMarketVolume = int(t.DayVolume)
+	}
+	if t.DayHigh != 0 {
+		q.RegularMarketDayHigh = t.DayHigh
+	}
+	if t.DayLow != 0 {
+		q.RegularMarketDayLow = t.DayLow
+	}
+	if t.OpenPrice != 0 {
+		q.RegularMarketOpen = t.OpenPrice
+	}
+	if t.PreviousClose != 0 {
+		q.RegularMarketPreviousClose = t.PreviousClose
+	}
+	if t.Bid != 0 || t.Ask != 0 {
+		q.Bid, q.BidSize, q.Ask, q.AskSize = t.Bid, int(t.BidSize), t.Ask, int(t.AskSize)
+	}
+	return q
+}
+
+// CheckTick checks the alerts on t.Symbol against the last Quote checked for the symbol, updated with the
+// fields of t. Fields that ticks do not carry, such as AverageDailyVolume10Day and FiftyTwoWeekHigh,
+// are therefore only known once a Quote of the symbol has been checked, e.g. by Watch.
+func (e *AlertEngine) CheckTick(t Tick) []AlertEvent {
+	e.mu.Lock()
+	q := mergeTick(e.last[t.Symbol], t)
+	e.mu.Unlock()
+	now := t.Time
+	if now.IsZero() {
+		now = time.Now()
+	}
+	return e.Check(q, now)
+}
+
+// WatchStreamer checks the alerts against every Tick received by s until its Ticks channel is closed,
+// which happens when s.Run returns. The ticks are consumed; to also use them elsewhere, call CheckTick
+// from your own receive loop instead.
+func (e *AlertEngine) WatchStreamer(s *Streamer) {
+	for t := range s.Ticks() {
+		e.CheckTick(t)
+	}
+}
+
+func (e *AlertEngine) reportErr(err error) {
+	if e.OnError != nil {
+		e.OnError(err)
+	}
+	if e.Verbose {
+		log.Println("alerts:", err)
+	}
+}
+
+// save writes the alerts and their state to StatePath. The file is replaced atomically so that
+// a crash while saving does not corrupt it. e.mu must be held.
+func (e *AlertEngine) save() error {
+	if e.StatePath == "" {
+		return nil
+	}
+	f := alertFile{State: e.state}
+	for _, a := range e.alerts {
+		f.Alerts = append(f.Alerts, a)
+	}
+	sort.Slice(f.Alerts, func(i, j int) bool { return f.Alerts[i].Id < f.Alerts[j].Id })
+	b, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(e.StatePath, b)
+}
@@ -0,0 +1,258 @@
+package yfi
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// errRequestPanicked is returned to the requests coalesced with one that panicked.
+var errRequestPanicked = errors.New("cached request panicked")
+
+// CacheStats contains the counters of a Cache. Coalesced counts the requests that were answered by
+// waiting for an identical request that was already in flight rather than by making one of their own.
+type CacheStats struct {
+	Hits      int64
+	Misses    int64
+	Coalesced int64
+	Expired   int64
+	Entries   int
+}
+
+// flight is a request that is in progress. done is closed once val and err are set.
+type flight struct {
+	done chan struct{}
+	val  any
+	err  error
+}
+
+type cacheEntry struct {
+	val     any
+	expires time.Time
+}
+
+// Cache is an in-memory cache of responses shared by the copies of a Client. Quotes are cached per symbol
+// for QuoteTTL and QuoteSummary responses per symbol and set of modules for SummaryTTL; a TTL of 0 disables
+// caching of the corresponding endpoint. Identical requests made while one is already in flight wait for
+// its response instead of being sent again. Errors are not cached. The zero value is an empty Cache that
+// caches nothing until its TTLs are set.
+//
+// Cached QuoteSummary values are shared between callers and must not be modified.
+type Cache struct {
+	QuoteTTL   time.Duration
+	SummaryTTL time.Duration
+
+	mu       sync.Mutex
+	entries  map[string]cacheEntry
+	inflight map[string]*flight
+	stats    CacheStats
+	now      func() time.Time
+}
+
+// NewCache returns a Cache with the given TTLs. Set Client.Cache to use it.
+func NewCache(quoteTTL, summaryTTL time.Duration) *Cache {
+	return &Cache{QuoteTTL: quoteTTL, SummaryTTL: summaryTTL}
+}
+
+// lazyInit initializes the maps of k if it was not created by NewCache. k.mu must be held.
+func (k *Cache) lazyInit() {
+	if k.entries == nil {
+		k.entries = make(map[string]cacheEntry)
+	}
+	if k.inflight == nil {
+		k.inflight = make(map[string]*flight)
+	}
+	if k.now == nil {
+		k.now = time.Now
+	}
+}
+
+// Stats returns the current counters of k.
+func (k *Cache) Stats() CacheStats {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	s := k.stats
+	s.Entries = len(k.entries)
+	return s
+}
+
+// Purge removes every entry from k.
+func (k *Cache) Purge() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.entries = make(map[string]cacheEntry)
+}
+
+// Prune removes the expired entries from k. Expired entries are otherwise only removed when they are requested again.
+func (k *Cache) Prune() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.lazyInit()
+	now := k.now()
+	for key, e := range k.entries {
+		if !now.Before(e.expires) {
+			delete(k.entries, key)
+			k.stats.Expired++
+		}
+	}
+}
+
+// acquire looks up key. If it is cached, its value is returned with ok set. Otherwise either the flight
+// of an identical request is returned as wait, or a new flight is registered and returned as own;
+// the caller must then complete it.
+func (k *Cache) acquire(key string) (val any, ok bool, wait, own *flight) {
+	k.lazyInit()
+	if e, found := k.entries[key]; found {
+		if k.now().Before(e.expires) {
+			k.stats.Hits++
+			return e.val, true, nil, nil
+		}
+		delete(k.entries, key)
+		k.stats.Expired++
+	}
+	if f, found := k.inflight[key]; found {
+		k.stats.Coalesced++
+		return nil, false, f, nil
+	}
+	k.stats.Misses++
+	f := &flight{done: make(chan struct{})}
+	k.inflight[key] = f
+	return nil, false, nil, f
+}
+
+// complete stores the result of the flight registered for key and wakes the requests waiting for it.
+// k.mu must be held.
+func (k *Cache) complete(key string, f *flight, val any, err error, ttl time.Duration) {
+	f.val, f.err = val, err
+	delete(k.inflight, key)
+	if err == nil {
+		k.entries[key] = cacheEntry{val: val, expires: k.now().Add(ttl)}
+	}
+	close(f.done)
+}
+
+// do returns the cached value of key, calling fn to compute it if necessary.
+func (k *Cache) do(key string, ttl time.Duration, fn func() (any, error)) (any, error) {
+	k.mu.Lock()
+	val, ok, wait, own := k.acquire(key)
+	k.mu.Unlock()
+	switch {
+	case ok:
+		return val, nil
+	case wait != nil:
+		<-wait.done
+		return wait.val, wait.err
+	}
+	// complete the flight even if fn panics, so that the requests waiting for it do not block forever
+	err := errRequestPanicked
+	defer func() {
+		k.mu.Lock()
+		k.complete(key, own, val, err, ttl)
+		k.mu.Unlock()
+	}()
+	val, err = fn()
+	return val, err
+}
+
+const quoteCachePrefix = "quote:"
+
+// getQuotes answers a GetQuotes request, fetching the symbols that are neither cached nor in flight with a single request.
+// Like GetQuotes, the result is keyed by the symbols of the returned Quotes. Symbols are cached case-insensitively,
+// and symbols that Yahoo does not return are cached as absent.
+func (k *Cache) getQuotes(c *Client, symbols []string) (map[string]Quote, error) {
+	res := make(map[string]Quote, len(symbols))
+	waits := make(map[string]*flight)
+	owned := make(map[string]*flight)
+	var missing []string
+	k.mu.Lock()
+	for _, s := range symbols {
+		key := quoteCachePrefix + strings.ToUpper(s)
+		if _, dup := owned[key]; dup {
+			continue
+		}
+		val, ok, wait, own := k.acquire(key)
+		switch {
+		case ok:
+			if q, isQuote := val.(Quote); isQuote {
+				res[q.Symbol] = q
+			}
+		case wait != nil:
+			waits[key] = wait
+		default:
+			owned[key] = own
+			missing = append(missing, s)
+		}
+	}
+	k.mu.Unlock()
+
+	var err error
+	if len(missing) > 0 {
+		err = k.fetchQuotes(c, missing, owned, res)
+	}
+	for _, f := range waits {
+		<-f.done
+		if f.err != nil && err == nil {
+			err = f.err
+		}
+		if q, isQuote := f.val.(Quote); isQuote {
+			res[q.Symbol] = q
+		}
+	}
+	return res, err
+}
+
+// fetchQuotes requests the Quotes of missing, adds them to res and completes the owned flights,
+// even if the request panics.
+func (k *Cache) fetchQuotes(c *Client, missing []string, owned map[string]*flight, res map[string]Quote) (err error) {
+	var quotes map[string]Quote
+	err = errRequestPanicked
+	defer func() {
+		bySymbol := make(map[string]Quote, len(quotes))
+		for _, q := range quotes {
+			bySymbol[quoteCachePrefix+strings.ToUpper(q.Symbol)] = q
+		}
+		k.mu.Lock()
+		defer k.mu.Unlock()
+		for key, f := range owned {
+			if q, found := bySymbol[key]; found {
+				k.complete(key, f, q, err, k.QuoteTTL)
+			} else {
+				k.complete(key, f, nil, err, k.QuoteTTL)
+			}
+		}
+	}()
+	uncached := *c
+	uncached.Cache = nil
+	quotes, err = uncached.GetQuotes(missing)
+	for _, q := range quotes {
+		res[q.Symbol] = q
+	}
+	return err
+}
+
+type cachedSummary struct {
+	summary QuoteSummary
+	report  ModuleReport
+}
+
+func (k *Cache) getQuoteSummaryReport(c *Client, symbol string, quoteParams []QuoteParam) (QuoteSummary, ModuleReport, error) {
+	params := make([]string, len(quoteParams))
+	for i, p := range quoteParams {
+		params[i] = string(p)
+	}
+	sort.Strings(params)
+	key := "summary:" + symbol + "?" + strings.Join(params, ",")
+	val, err := k.do(key, k.SummaryTTL, func() (any, error) {
+		uncached := *c
+		uncached.Cache = nil
+		summary, report, err := uncached.GetQuoteSummaryReport(symbol, quoteParams)
+		return cachedSummary{summary, report}, err
+	})
+	cs, _ := val.(cachedSummary)
+	if cs.summary == nil {
+		cs.summary = make(QuoteSummary)
+	}
+	return cs.summary, cs.report, err
+}
@@ -0,0 +1,328 @@
+package yfi
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CalendarEventType identifies the kind of a CalendarEvent.
+type CalendarEventType string
+
+const (
+	EarningsEvent        CalendarEventType = "earnings"
+	ExDividendEvent      CalendarEventType = "exDividend"
+	DividendPaymentEvent CalendarEventType = "dividend"
+	IPOEvent             CalendarEventType = "ipo"
+)
+
+// CalendarEvent is a dated corporate event. EndDate is set when Yahoo only provides a range of
+// dates for an upcoming earnings report. Fields that do not apply to the event's Type are not Valid.
+type CalendarEvent struct {
+	Symbol  string
+	Name    string
+	Type    CalendarEventType
+	Date    time.Time
+	EndDate time.Time
+	// TimeType describes when an event takes place during the day, e.g. "BMO" (before market open) or "AMC" (after market close).
+	TimeType        string
+	EarningsAverage Value
+	EarningsLow     Value
+	EarningsHigh    Value
+	EarningsActual  Value
+	SurprisePercent Value
+	RevenueAverage  Value
+	RevenueLow      Value
+	RevenueHigh     Value
+	OfferPrice      Value
+	Exchange        string
+}
+
+// Calendar contains CalendarEvents sorted by Date. Errors contains the symbols for which
+// events could not be retrieved by GetCalendar.
+type Calendar struct {
+	Events []CalendarEvent
+	Errors map[string]error
+}
+
+func (cal *Calendar) sort() {
+	sort.SliceStable(cal.Events, func(i, j int) bool {
+		if !cal.Events[i].Date.Equal(cal.Events[j].Date) {
+			return cal.Events[i].Date.Before(cal.Events[j].Date)
+		}
+		return cal.Events[i].Symbol < cal.Events[j].Symbol
+	})
+}
+
+// GetCalendar retrieves the upcoming earnings, ex-dividend and dividend payment dates of symbols
+// from their calendarEvents modules, keeping the events that fall between from and to.
+// Requests are made with GetQuoteSummaries.
+func (c *Client) GetCalendar(symbols []string, from, to time.Time) Calendar {
+	cal := Calendar{Errors: make(map[string]error)}
+	results := c.GetQuoteSummaries(symbols, []QuoteParam{CalendarEvents, Price})
+	for _, r := range results {
+		if r.Err != nil {
+			cal.Errors[r.Symbol] = r.Err
+			continue
+		}
+		var ce CalendarEventsModule
+		err := DecodeModule(r.Summary, CalendarEvents, &ce)
+		if err != nil {
+			cal.Errors[r.Symbol] = err
+			continue
+		}
+		name, _ := r.Summary.String("price.shortName")
+		inRange := func(t time.Time) bool {
+			return !t.IsZero() && !t.Before(from) && t.Before(to)
+		}
+
+		dates := ce.Earnings.EarningsDates()
+		if len(dates) > 0 && (inRange(dates[0]) || inRange(dates[len(dates)-1])) {
+			ev := CalendarEvent{
+				Symbol:          r.Symbol,
+				Name:            name,
+				Type:            EarningsEvent,
+				Date:            dates[0],
+				EarningsAverage: ce.Earnings.EarningsAverage,
+				EarningsLow:     ce.Earnings.EarningsLow,
+				EarningsHigh:    ce.Earnings.EarningsHigh,
+				RevenueAverage:  ce.Earnings.RevenueAverage,
+				RevenueLow:      ce.Earnings.RevenueLow,
+				RevenueHigh:     ce.Earnings.RevenueHigh,
+			}
+			if len(dates) > 1 {
+				ev.EndDate = dates[len(dates)-1]
+			}
+			cal.Events = append(cal.Events, ev)
+		}
+		if t := ce.ExDividendDate.Time(); inRange(t) {
+			cal.Events = append(cal.Events, CalendarEvent{Symbol: r.Symbol, Name: name, Type: ExDividendEvent, Date: t})
+		}
+		if t := ce.DividendDate.Time(); inRange(t) {
+			cal.Events = append(cal.Events, CalendarEvent{Symbol: r.Symbol, Name: name, Type: DividendPaymentEvent, Date: t})
+		}
+	}
+	cal.sort()
+	return cal
+}
+
+type outerVisualizationResp struct {
+	Finance visualizationResp `json:"finance"`
+}
+
+type visualizationResp struct {
+	Result []struct {
+		Documents []visualizationDoc `json:"documents"`
+	} `json:"result"`
+	Error any `json:"error"`
+}
+
+type visualizationDoc struct {
+	Columns []struct {
+		Id string `json:"id"`
+	} `json:"columns"`
+	Rows [][]any `json:"rows"`
+}
+
+type visualizationBody struct {
+	SortType      string        `json:"sortType"`
+	SortField     string        `json:"sortField"`
+	EntityIdType  string        `json:"entityIdType"`
+	IncludeFields []string      `json:"includeFields"`
+	Query         ScreenerQuery `json:"query"`
+	Offset        int           `json:"offset"`
+	Size          int           `json:"size"`
+}
+
+const visualizationPageSize = 100
+
+// GetEarningsCalendar retrieves every earnings report scheduled between from and to in region, e.g. "us",
+// from Yahoo's visualization endpoint.
+func (c *Client) GetEarningsCalendar(region string, from, to time.Time) (Calendar, error) {
+	fields := []string{"ticker", "companyshortname", "startdatetime", "startdatetimetype", "epsestimate", "epsactual", "epssurprisepct"}
+	return c.getVisualizationCalendar("earnings", "startdatetime", fields, region, from, to, func(row map[string]any) CalendarEvent {
+		return CalendarEvent{
+			Type:            EarningsEvent,
+			TimeType:        rowString(row, "startdatetimetype"),
+			EarningsAverage: rowValue(row, "epsestimate"),
+			EarningsActual:  rowValue(row, "epsactual"),
+			SurprisePercent: rowValue(row, "epssurprisepct"),
+		}
+	})
+}
+
+// GetIPOCalendar retrieves every IPO scheduled between from and to in region from Yahoo's visualization endpoint.
+func (c *Client) GetIPOCalendar(region string, from, to time.Time) (Calendar, error) {
+	fields := []string{"ticker", "companyshortname", "startdatetime", "exchange_short_name", "offerprice"}
+	return c.getVisualizationCalendar("ipo_info", "startdatetime", fields, region, from, to, func(row map[string]any) CalendarEvent {
+		return CalendarEvent{
+			Type:       IPOEvent,
+			Exchange:   rowString(row, "exchange_short_name"),
+			OfferPrice: rowValue(row, "offerprice"),
+		}
+	})
+}
+
+func (c *Client) getVisualizationCalendar(entity, dateField string, fields []string, region string, from, to time.Time, parse func(map[string]any) CalendarEvent) (Calendar, error) {
+	var cal Calendar
+	query := And(
+		Gte(dateField, from.UTC().Format("2006-01-02")),
+		Lt(dateField, to.UTC().Format("2006-01-02")),
+		Eq("region", strings.ToLower(region)),
+	)
+	for offset := 0; ; offset += visualizationPageSize {
+		if offset > 0 {
+			time.Sleep(c.WaitPeriod)
+		}
+		body := visualizationBody{
+			SortType:      "ASC",
+			SortField:     dateField,
+			EntityIdType:  entity,
+			IncludeFields: fields,
+			Query:         query,
+			Offset:        offset,
+			Size:          visualizationPageSize,
+		}
+		var v outerVisualizationResp
+		err := c.doJSON(http.MethodPost, V1+"visualization?lang=en-US&region="+strings.ToUpper(region), body, &v)
+		if err != nil {
+			return cal, err
+		}
+		if len(v.Finance.Result) == 0 || len(v.Finance.Result[0].Documents) == 0 {
+			return cal, ErrMalformedResp
+		}
+		doc := v.Finance.Result[0].Documents[0]
+		for _, r := range doc.Rows {
+			row := make(map[string]any, len(doc.Columns))
+			for i := 0; i < len(doc.Columns) && i < len(r); i++ {
+				row[doc.Columns[i].Id] = r[i]
+			}
+			date, err := time.Parse(time.RFC3339, rowString(row, dateField))
+			if err != nil {
+				return cal, ErrMalformedResp
+			}
+			ev := parse(row)
+			ev.Symbol = rowString(row, "ticker")
+			ev.Name = rowString(row, "companyshortname")
+			ev.Date = date
+			cal.Events = append(cal.Events, ev)
+		}
+		if len(doc.Rows) < visualizationPageSize {
+			break
+		}
+	}
+	cal.sort()
+	return cal, nil
+}
+
+func rowString(row map[string]any, key string) string {
+	s, _ := row[key].(string)
+	return s
+}
+
+func rowValue(row map[string]any, key string) Value {
+	f, ok := row[key].(float64)
+	return Value{Raw: f, Valid: ok}
+}
+
+// WriteICS writes the events of cal to w as an iCalendar (RFC 5545) file, so that it can be imported
+// into or subscribed to from calendar applications. Events are written as all-day events.
+func (cal Calendar) WriteICS(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	stamp := time.Now().UTC().Format("20060102T150405Z")
+	writeLine := func(line string) {
+		// lines longer than 75 octets are folded onto continuation lines beginning with a space
+		for len(line) > 75 {
+			cut := 75
+			for cut > 0 && line[cut]&0xC0 == 0x80 {
+				cut-- // don't split UTF-8 sequences
+			}
+			bw.WriteString(line[:cut] + "\r\n")
+			line = " " + line[cut:]
+		}
+		bw.WriteString(line + "\r\n")
+	}
+
+	writeLine("BEGIN:VCALENDAR")
+	writeLine("VERSION:2.0")
+	writeLine("PRODID:-//yfi//calendar//EN")
+	writeLine("CALSCALE:GREGORIAN")
+	for _, ev := range cal.Events {
+		start := ev.Date.UTC()
+		end := start
+		if !ev.EndDate.IsZero() {
+			end = ev.EndDate.UTC()
+		}
+		end = end.AddDate(0, 0, 1) // DTEND is exclusive
+		writeLine("BEGIN:VEVENT")
+		writeLine("UID:" + ev.Symbol + "-" + string(ev.Type) + "-" + start.Format("20060102") + "@yfi")
+		writeLine("DTSTAMP:" + stamp)
+		writeLine("DTSTART;VALUE=DATE:" + start.Format("20060102"))
+		writeLine("DTEND;VALUE=DATE:" + end.Format("20060102"))
+		writeLine("SUMMARY:" + icsEscape(ev.summary()))
+		if d := ev.description(); d != "" {
+			writeLine("DESCRIPTION:" + icsEscape(d))
+		}
+		writeLine("END:VEVENT")
+	}
+	writeLine("END:VCALENDAR")
+	return bw.Flush()
+}
+
+func (ev CalendarEvent) summary() string {
+	var kind string
+	switch ev.Type {
+	case EarningsEvent:
+		kind = "earnings"
+		if !ev.EndDate.IsZero() {
+			kind += " (estimated)"
+		}
+	case ExDividendEvent:
+		kind = "ex-dividend"
+	case DividendPaymentEvent:
+		kind = "dividend payment"
+	case IPOEvent:
+		kind = "IPO"
+	default:
+		kind = string(ev.Type)
+	}
+	return ev.Symbol + " " + kind
+}
+
+func (ev CalendarEvent) description() string {
+	var parts []string
+	if ev.Name != "" {
+		parts = append(parts, ev.Name)
+	}
+	if ev.TimeType != "" {
+		parts = append(parts, "Time: "+ev.TimeType)
+	}
+	add := func(label string, v Value) {
+		if v.Valid {
+			parts = append(parts, label+": "+strconv.FormatFloat(v.Raw, 'f', -1, 64))
+		}
+	}
+	add("EPS estimate", ev.EarningsAverage)
+	add("EPS low", ev.EarningsLow)
+	add("EPS high", ev.EarningsHigh)
+	add("EPS actual", ev.EarningsActual)
+	add("Surprise %", ev.SurprisePercent)
+	add("Revenue estimate", ev.RevenueAverage)
+	add("Revenue low", ev.RevenueLow)
+	add("Revenue high", ev.RevenueHigh)
+	add("Offer price", ev.OfferPrice)
+	if ev.Exchange != "" {
+		parts = append(parts, "Exchange: "+ev.Exchange)
+	}
+	return strings.Join(parts, "\n")
+}
+
+var icsEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`)
+
+func icsEscape(s string) string {
+	return icsEscaper.Replace(s)
+}
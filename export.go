@@ -0,0 +1,268 @@
+package yfi
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"math"
+	"strconv"
+	"time"
+)
+
+// Column identifies a column of an exported Ticker history. The value of each Column is its default header.
+type Column string
+
+const (
+	ColSymbol   Column = "Symbol"
+	ColInterval Column = "Interval"
+	ColDate     Column = "Date"
+	ColOpen     Column = "Open"
+	ColHigh     Column = "High"
+	ColLow      Column = "Low"
+	ColClose    Column = "Close"
+	ColAdjClose Column = "Adj Close"
+	ColVolume   Column = "Volume"
+)
+
+// DefaultColumns are the columns written by ToCsv, in order.
+var DefaultColumns = []Column{ColDate, ColOpen, ColHigh, ColLow, ColClose, ColAdjClose, ColVolume}
+
+// DateFormat determines how dates are exported.
+type DateFormat int
+
+const (
+	// UnixDate writes dates as Unix seconds.
+	UnixDate DateFormat = iota
+	// RFC3339Date writes dates as RFC 3339 times in the Location of the ExportOptions.
+	RFC3339Date
+	// LocalDate writes dates as 2006-01-02 in the Location of the ExportOptions, which should be the exchange's time zone.
+	LocalDate
+)
+
+// JSONLayout determines the structure of exported JSON.
+type JSONLayout int
+
+const (
+	// ColumnJSON writes an object mapping each header to an array of values. ReadJSON reads it back as long as
+	// the default headers are used.
+	ColumnJSON JSONLayout = iota
+	// RowJSON writes an array of objects, one per bar, mapping headers to values.
+	RowJSON
+	// NDJSON writes one object per bar per line (newline-delimited JSON).
+	NDJSON
+)
+
+// ExportOptions configure WriteCSV and WriteJSON. The zero value writes the DefaultColumns with Unix dates
+// and floats in the shortest representation that round-trips exactly, which reproduces the output of ToCsv
+// and writes JSON that ReadJSON can read back.
+type ExportOptions struct {
+	DateFormat DateFormat
+	// Location is used to format RFC3339Date and LocalDate dates. A nil Location means UTC.
+	Location *time.Location
+	// Columns are the columns to write, in order. If empty, DefaultColumns are written.
+	Columns []Column
+	// FixedPrecision, if set, writes floats with Precision decimals instead of their shortest exact representation.
+	FixedPrecision bool
+	Precision      int
+	// Headers overrides the header of the given columns.
+	Headers map[Column]string
+	JSON    JSONLayout
+}
+
+// DefaultExportOptions returns the options that reproduce the output of ToCsv, which are the zero value.
+func DefaultExportOptions() ExportOptions {
+	return ExportOptions{}
+}
+
+func (o ExportOptions) columns() []Column {
+	if len(o.Columns) == 0 {
+		return DefaultColumns
+	}
+	return o.Columns
+}
+
+func (o ExportOptions) header(col Column) string {
+	if h, ok := o.Headers[col]; ok {
+		return h
+	}
+	return string(col)
+}
+
+func (o ExportOptions) validate(tickers []Ticker) error {
+	for _, col := range o.columns() {
+		switch col {
+		case ColSymbol, ColInterval, ColDate, ColOpen, ColHigh, ColLow, ColClose, ColAdjClose, ColVolume:
+		default:
+			return ErrColumn
+		}
+	}
+	for i := range tickers {
+		if err := tickers[i].validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cell formats column col of the i-th bar of t. raw reports whether s is a JSON number or null
+// that can be written to JSON as is. Floats that are not finite are written as null, like Yahoo does.
+func (o ExportOptions) cell(t *Ticker, i int, col Column) (s string, raw bool) {
+	f := func(v float64) (string, bool) {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return "null", true
+		}
+		if !o.FixedPrecision {
+			return strconv.FormatFloat(v, 'f', -1, 64), true
+		}
+		return strconv.FormatFloat(v, 'f', o.Precision, 64), true
+	}
+	switch col {
+	case ColSymbol:
+		return t.Symbol, false
+	case ColInterval:
+		return string(t.Interval), false
+	case ColDate:
+		loc := o.Location
+		if loc == nil {
+			loc = time.UTC
+		}
+		switch o.DateFormat {
+		case RFC3339Date:
+			return time.Unix(t.HistoricDates[i], 0).In(loc).Format(time.RFC3339), false
+		case LocalDate:
+			return time.Unix(t.HistoricDates[i], 0).In(loc).Format("2006-01-02"), false
+		}
+		return strconv.FormatInt(t.HistoricDates[i], 10), true
+	case ColOpen:
+		return f(t.HistoricOpen[i])
+	case ColHigh:
+		return f(t.HistoricHigh[i])
+	case ColLow:
+		return f(t.HistoricLow[i])
+	case ColClose:
+		return f(t.HistoricClose[i])
+	case ColAdjClose:
+		return f(t.HistoricAdjClose[i])
+	case ColVolume:
+		return strconv.Itoa(t.HistoricVolume[i]), true
+	}
+	return "", false
+}
+
+// WriteCSV writes the history of t to w as CSV, formatted according to opts.
+func (t *Ticker) WriteCSV(w io.Writer, opts ExportOptions) error {
+	return WriteTickersCSV(w, []Ticker{*t}, opts)
+}
+
+// WriteJSON writes the history of t to w as JSON, formatted according to opts.
+func (t *Ticker) WriteJSON(w io.Writer, opts ExportOptions) error {
+	return WriteTickersJSON(w, []Ticker{*t}, opts)
+}
+
+// WriteTickersCSV writes the histories of tickers to w as a single CSV file with one header row.
+// Include ColSymbol in opts.Columns to tell the tickers apart.
+func WriteTickersCSV(w io.Writer, tickers []Ticker, opts ExportOptions) error {
+	if err := opts.validate(tickers); err != nil {
+		return err
+	}
+	cols := opts.columns()
+	cw := csv.NewWriter(w)
+	record := make([]string, len(cols))
+	for j, col := range cols {
+		record[j] = opts.header(col)
+	}
+	if err := cw.Write(record); err != nil {
+		return err
+	}
+	for k := range tickers {
+		t := &tickers[k]
+		for i := range t.HistoricDates {
+			for j, col := range cols {
+				record[j], _ = opts.cell(t, i, col)
+			}
+			if err := cw.Write(record); err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteTickersJSON writes the histories of tickers to w as JSON, in the layout selected by opts.JSON.
+func WriteTickersJSON(w io.Writer, tickers []Ticker, opts ExportOptions) error {
+	if err := opts.validate(tickers); err != nil {
+		return err
+	}
+	cols := opts.columns()
+	keys := make([][]byte, len(cols))
+	for j, col := range cols {
+		keys[j], _ = json.Marshal(opts.header(col))
+	}
+	bw := bufio.NewWriter(w)
+	writeCell := func(t *Ticker, i int, col Column) {
+		s, raw := opts.cell(t, i, col)
+		if raw {
+			bw.WriteString(s)
+		} else {
+			b, _ := json.Marshal(s)
+			bw.Write(b)
+		}
+	}
+
+	if opts.JSON == ColumnJSON {
+		bw.WriteByte('{')
+		for j, col := range cols {
+			if j > 0 {
+				bw.WriteByte(',')
+			}
+			bw.Write(keys[j])
+			bw.WriteString(":[")
+			first := true
+			for k := range tickers {
+				for i := range tickers[k].HistoricDates {
+					if !first {
+						bw.WriteByte(',')
+					}
+					first = false
+					writeCell(&tickers[k], i, col)
+				}
+			}
+			bw.WriteByte(']')
+		}
+		bw.WriteString("}\n")
+		return bw.Flush()
+	}
+
+	if opts.JSON == RowJSON {
+		bw.WriteByte('[')
+	}
+	first := true
+	for k := range tickers {
+		t := &tickers[k]
+		for i := range t.HistoricDates {
+			if opts.JSON == RowJSON && !first {
+				bw.WriteByte(',')
+			}
+			first = false
+			bw.WriteByte('{')
+			for j, col := range cols {
+				if j > 0 {
+					bw.WriteByte(',')
+				}
+				bw.Write(keys[j])
+				bw.WriteByte(':')
+				writeCell(t, i, col)
+			}
+			bw.WriteByte('}')
+			if opts.JSON == NDJSON {
+				bw.WriteByte('\n')
+			}
+		}
+	}
+	if opts.JSON == RowJSON {
+		bw.WriteString("]\n")
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,322 @@
+package yfi
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// FundamentalsFreq is the reporting frequency of a fundamentals timeseries.
+type FundamentalsFreq string
+
+const (
+	Annual    FundamentalsFreq = "annual"
+	Quarterly FundamentalsFreq = "quarterly"
+	// Trailing series cover the trailing twelve months and are only available for income statement and cash flow items.
+	Trailing FundamentalsFreq = "trailing"
+)
+
+// Statement identifies the financial statement a FundamentalsItem belongs to.
+type Statement int
+
+const (
+	IncomeStatement Statement = iota
+	BalanceSheet
+	CashFlowStatement
+)
+
+// FundamentalsItem is a financial statement line item provided by the fundamentals-timeseries endpoint.
+type FundamentalsItem string
+
+const (
+	// income statement
+	TotalRevenue                    FundamentalsItem = "TotalRevenue"
+	CostOfRevenue                   FundamentalsItem = "CostOfRevenue"
+	GrossProfit                     FundamentalsItem = "GrossProfit"
+	ResearchAndDevelopment          FundamentalsItem = "ResearchAndDevelopment"
+	SellingGeneralAndAdministration FundamentalsItem = "SellingGeneralAndAdministration"
+	OperatingExpense                FundamentalsItem = "OperatingExpense"
+	OperatingIncome                 FundamentalsItem = "OperatingIncome"
+	TotalExpenses                   FundamentalsItem = "TotalExpenses"
+	InterestExpense                 FundamentalsItem = "InterestExpense"
+	PretaxIncome                    FundamentalsItem = "PretaxIncome"
+	TaxProvision                    FundamentalsItem = "TaxProvision"
+	NetIncome                       FundamentalsItem = "NetIncome"
+	NetIncomeCommonStockholders     FundamentalsItem = "NetIncomeCommonStockholders"
+	BasicEPS                        FundamentalsItem = "BasicEPS"
+	DilutedEPS                      FundamentalsItem = "DilutedEPS"
+	BasicAverageShares              FundamentalsItem = "BasicAverageShares"
+	DilutedAverageShares            FundamentalsItem = "DilutedAverageShares"
+	EBIT                            FundamentalsItem = "EBIT"
+	EBITDA                          FundamentalsItem = "EBITDA"
+
+	// balance sheet
+	TotalAssets                                FundamentalsItem = "TotalAssets"
+	CurrentAssets                              FundamentalsItem = "CurrentAssets"
+	CashAndCashEquivalents                     FundamentalsItem = "CashAndCashEquivalents"
+	CashCashEquivalentsAndShortTermInvestments FundamentalsItem = "CashCashEquivalentsAndShortTermInvestments"
+	AccountsReceivable                         FundamentalsItem = "AccountsReceivable"
+	Inventory                                  FundamentalsItem = "Inventory"
+	NetPPE                                     FundamentalsItem = "NetPPE"
+	TotalLiabilitiesNetMinorityInterest        FundamentalsItem = "TotalLiabilitiesNetMinorityInterest"
+	CurrentLiabilities                         FundamentalsItem = "CurrentLiabilities"
+	LongTermDebt                               FundamentalsItem = "LongTermDebt"
+	TotalDebt                                  FundamentalsItem = "TotalDebt"
+	NetDebt                                    FundamentalsItem = "NetDebt"
+	StockholdersEquity                         FundamentalsItem = "StockholdersEquity"
+	RetainedEarnings                           FundamentalsItem = "RetainedEarnings"
+	WorkingCapital                             FundamentalsItem = "WorkingCapital"
+	TangibleBookValue                          FundamentalsItem = "TangibleBookValue"
+	OrdinarySharesNumber                       FundamentalsItem = "OrdinarySharesNumber"
+
+	// cash flow statement
+	OperatingCashFlow             FundamentalsItem = "OperatingCashFlow"
+	InvestingCashFlow             FundamentalsItem = "InvestingCashFlow"
+	FinancingCashFlow             FundamentalsItem = "FinancingCashFlow"
+	FreeCashFlow                  FundamentalsItem = "FreeCashFlow"
+	CapitalExpenditure            FundamentalsItem = "CapitalExpenditure"
+	DepreciationAndAmortization   FundamentalsItem = "DepreciationAndAmortization"
+	StockBasedCompensation        FundamentalsItem = "StockBasedCompensation"
+	ChangeInWorkingCapital        FundamentalsItem = "ChangeInWorkingCapital"
+	RepurchaseOfCapitalStock      FundamentalsItem = "RepurchaseOfCapitalStock"
+	CashDividendsPaid             FundamentalsItem = "CashDividendsPaid"
+	EndCashPosition               FundamentalsItem = "EndCashPosition"
+	IssuanceOfDebt                FundamentalsItem = "IssuanceOfDebt"
+	RepaymentOfDebt               FundamentalsItem = "RepaymentOfDebt"
+	ChangesInCash                 FundamentalsItem = "ChangesInCash"
+	NetIncomeFromContinuingOps    FundamentalsItem = "NetIncomeFromContinuingOperations"
+	CashFlowFromContinuingOps     FundamentalsItem = "CashFlowFromContinuingOperatingActivities"
+	PurchaseOfInvestment          FundamentalsItem = "PurchaseOfInvestment"
+	SaleOfInvestment              FundamentalsItem = "SaleOfInvestment"
+	CommonStockIssuance           FundamentalsItem = "CommonStockIssuance"
+	NetCommonStockIssuance        FundamentalsItem = "NetCommonStockIssuance"
+	NetIssuancePaymentsOfDebt     FundamentalsItem = "NetIssuancePaymentsOfDebt"
+	IncomeTaxPaidSupplementalData FundamentalsItem = "IncomeTaxPaidSupplementalData"
+	InterestPaidSupplementalData  FundamentalsItem = "InterestPaidSupplementalData"
+)
+
+// FundamentalsCatalog maps each supported FundamentalsItem to the Statement it belongs to.
+var FundamentalsCatalog = map[FundamentalsItem]Statement{
+	TotalRevenue:                    IncomeStatement,
+	CostOfRevenue:                   IncomeStatement,
+	GrossProfit:                     IncomeStatement,
+	ResearchAndDevelopment:          IncomeStatement,
+	SellingGeneralAndAdministration: IncomeStatement,
+	OperatingExpense:                IncomeStatement,
+	OperatingIncome:                 IncomeStatement,
+	TotalExpenses:                   IncomeStatement,
+	InterestExpense:                 IncomeStatement,
+	PretaxIncome:                    IncomeStatement,
+	TaxProvision:                    IncomeStatement,
+	NetIncome:                       IncomeStatement,
+	NetIncomeCommonStockholders:     IncomeStatement,
+	BasicEPS:                        IncomeStatement,
+	DilutedEPS:                      IncomeStatement,
+	BasicAverageShares:              IncomeStatement,
+	DilutedAverageShares:            IncomeStatement,
+	EBIT:                            IncomeStatement,
+	EBITDA:                          IncomeStatement,
+
+	TotalAssets:            BalanceSheet,
+	CurrentAssets:          BalanceSheet,
+	CashAndCashEquivalents: BalanceSheet,
+	CashCashEquivalentsAndShortTermInvestments: BalanceSheet,
+	AccountsReceivable:                         BalanceSheet,
+	Inventory:                                  BalanceSheet,
+	NetPPE:                                     BalanceSheet,
+	TotalLiabilitiesNetMinorityInterest:        BalanceSheet,
+	CurrentLiabilities:                         BalanceSheet,
+	LongTermDebt:                               BalanceSheet,
+	TotalDebt:                                  BalanceSheet,
+	NetDebt:                                    BalanceSheet,
+	StockholdersEquity:                         BalanceSheet,
+	RetainedEarnings:                           BalanceSheet,
+	WorkingCapital:                             BalanceSheet,
+	TangibleBookValue:                          BalanceSheet,
+	OrdinarySharesNumber:                       BalanceSheet,
+
+	OperatingCashFlow:             CashFlowStatement,
+	InvestingCashFlow:             CashFlowStatement,
+	FinancingCashFlow:             CashFlowStatement,
+	FreeCashFlow:                  CashFlowStatement,
+	CapitalExpenditure:            CashFlowStatement,
+	DepreciationAndAmortization:   CashFlowStatement,
+	StockBasedCompensation:        CashFlowStatement,
+	ChangeInWorkingCapital:        CashFlowStatement,
+	RepurchaseOfCapitalStock:      CashFlowStatement,
+	CashDividendsPaid:             CashFlowStatement,
+	EndCashPosition:               CashFlowStatement,
+	IssuanceOfDebt:                CashFlowStatement,
+	RepaymentOfDebt:               CashFlowStatement,
+	ChangesInCash:                 CashFlowStatement,
+	NetIncomeFromContinuingOps:    CashFlowStatement,
+	CashFlowFromContinuingOps:     CashFlowStatement,
+	PurchaseOfInvestment:          CashFlowStatement,
+	SaleOfInvestment:              CashFlowStatement,
+	CommonStockIssuance:           CashFlowStatement,
+	NetCommonStockIssuance:        CashFlowStatement,
+	NetIssuancePaymentsOfDebt:     CashFlowStatement,
+	IncomeTaxPaidSupplementalData: CashFlowStatement,
+	InterestPaidSupplementalData:  CashFlowStatement,
+}
+
+// FundamentalsKey returns the name Yahoo uses for the timeseries of item at the given frequency, e.g. "annualTotalRevenue".
+func FundamentalsKey(freq FundamentalsFreq, item FundamentalsItem) string {
+	return string(freq) + string(item)
+}
+
+func validateFundamentals(freq FundamentalsFreq, item FundamentalsItem) error {
+	statement, ok := FundamentalsCatalog[item]
+	if !ok {
+		return ErrFundamentals
+	}
+	switch freq {
+	case Annual, Quarterly:
+		return nil
+	case Trailing:
+		if statement == BalanceSheet {
+			return ErrFundamentals
+		}
+		return nil
+	default:
+		return ErrFundamentals
+	}
+}
+
+// FundamentalsPoint is a single reported value of a fundamentals timeseries.
+type FundamentalsPoint struct {
+	AsOfDate      time.Time
+	PeriodType    string
+	CurrencyCode  string
+	ReportedValue Value
+}
+
+// FundamentalsSeries contains the reported values of a single line item, sorted by AsOfDate.
+type FundamentalsSeries struct {
+	Freq   FundamentalsFreq
+	Item   FundamentalsItem
+	Points []FundamentalsPoint
+}
+
+// Fundamentals contains the fundamentals timeseries for a symbol, keyed by FundamentalsKey.
+type Fundamentals struct {
+	Symbol string
+	Series map[string]FundamentalsSeries
+}
+
+// Get returns the series for item at the given frequency. The returned series has no Points if it was not part of the response.
+func (f Fundamentals) Get(freq FundamentalsFreq, item FundamentalsItem) FundamentalsSeries {
+	s, ok := f.Series[FundamentalsKey(freq, item)]
+	if !ok {
+		return FundamentalsSeries{Freq: freq, Item: item}
+	}
+	return s
+}
+
+type outerTimeseriesResp struct {
+	Timeseries timeseriesResp `json:"timeseries"`
+}
+
+type timeseriesResp struct {
+	Result []map[string]json.RawMessage `json:"result"`
+	Error  any                          `json:"error"`
+}
+
+type timeseriesMeta struct {
+	Symbol []string `json:"symbol"`
+	Type   []string `json:"type"`
+}
+
+type timeseriesPoint struct {
+	AsOfDate      string `json:"asOfDate"`
+	PeriodType    string `json:"periodType"`
+	CurrencyCode  string `json:"currencyCode"`
+	ReportedValue Value  `json:"reportedValue"`
+}
+
+// GetFundamentalsTimeseries retrieves the timeseries of each item at each of the given frequencies,
+// restricted to periods ending between startDate and endDate. Unlike the statement modules of GetQuoteSummary,
+// which provide only the 4 most recent periods, the fundamentals-timeseries endpoint provides many years of history.
+// ErrFundamentals is returned if any item is not part of the FundamentalsCatalog or is not available at a given frequency.
+func (c *Client) GetFundamentalsTimeseries(symbol string, freqs []FundamentalsFreq, items []FundamentalsItem, startDate, endDate time.Time) (Fundamentals, error) {
+	res := Fundamentals{Symbol: symbol, Series: make(map[string]FundamentalsSeries)}
+	if len(freqs) == 0 || len(items) == 0 {
+		return res, ErrFundamentals
+	}
+	if endDate.Before(startDate) {
+		return res, ErrRange
+	}
+	types := ""
+	known := make(map[string]FundamentalsSeries, len(freqs)*len(items))
+	for _, freq := range freqs {
+		for _, item := range items {
+			err := validateFundamentals(freq, item)
+			if err != nil {
+				return res, err
+			}
+			key := FundamentalsKey(freq, item)
+			known[key] = FundamentalsSeries{Freq: freq, Item: item}
+			types += key + ","
+		}
+	}
+	types = types[:len(types)-1] // trim final comma
+
+	url := FUNDAMENTALS + symbol + "?symbol=" + symbol +
+		"&type=" + types +
+		"&period1=" + strconv.Itoa(int(startDate.Unix())) +
+		"&period2=" + strconv.Itoa(int(endDate.Unix())) +
+		"&merge=false&padTimeSeries=false"
+
+	var v outerTimeseriesResp
+	err := c.getJSON(url, &v)
+	if err != nil {
+		return res, err
+	}
+	if v.Timeseries.Result == nil {
+		return res, ErrMalformedResp
+	}
+
+	for _, result := range v.Timeseries.Result {
+		var meta timeseriesMeta
+		err = json.Unmarshal(result["meta"], &meta)
+		if err != nil || len(meta.Type) == 0 {
+			return res, ErrMalformedResp
+		}
+		key := meta.Type[0]
+		series, ok := known[key]
+		if !ok {
+			continue
+		}
+		raw, ok := result[key]
+		if !ok {
+			// Yahoo omits the values of series without any data
+			continue
+		}
+		var points []*timeseriesPoint
+		err = json.Unmarshal(raw, &points)
+		if err != nil {
+			return res, ErrMalformedResp
+		}
+		for _, p := range points {
+			if p == nil {
+				continue
+			}
+			date, err := time.Parse("2006-01-02", p.AsOfDate)
+			if err != nil {
+				return res, ErrMalformedResp
+			}
+			series.Points = append(series.Points, FundamentalsPoint{
+				AsOfDate:      date,
+				PeriodType:    p.PeriodType,
+				CurrencyCode:  p.CurrencyCode,
+				ReportedValue: p.ReportedValue,
+			})
+		}
+		sort.Slice(series.Points, func(i, j int) bool {
+			return series.Points[i].AsOfDate.Before(series.Points[j].AsOfDate)
+		})
+		res.Series[key] = series
+	}
+	return res, nil
+}
@@ -0,0 +1,127 @@
+package yfi
+
+import (
+	"net/url"
+)
+
+type outerInsightsResp struct {
+	Finance insightsResp `json:"finance"`
+}
+
+type insightsResp struct {
+	Result *Insights `json:"result"`
+	Error  any       `json:"error"`
+}
+
+// Insights contains Yahoo's technical and fundamental commentary about an asset.
+type Insights struct {
+	Symbol          string                   `json:"symbol"`
+	InstrumentInfo  InstrumentInfo           `json:"instrumentInfo"`
+	CompanySnapshot CompanySnapshot          `json:"companySnapshot"`
+	Recommendation  InsightRecommendation    `json:"recommendation"`
+	Reports         []ResearchReport         `json:"reports"`
+	SigDevs         []SignificantDevelopment `json:"sigDevs"`
+}
+
+// InstrumentInfo contains the technical outlooks, key levels and valuation of an asset.
+type InstrumentInfo struct {
+	TechnicalEvents TechnicalEvents `json:"technicalEvents"`
+	KeyTechnicals   KeyTechnicals   `json:"keyTechnicals"`
+	Valuation       Valuation       `json:"valuation"`
+}
+
+// TechnicalEvents contains the short, intermediate and long-term technical outlooks of an asset.
+type TechnicalEvents struct {
+	Provider                string  `json:"provider"`
+	Sector                  string  `json:"sector"`
+	ShortTermOutlook        Outlook `json:"shortTermOutlook"`
+	IntermediateTermOutlook Outlook `json:"intermediateTermOutlook"`
+	LongTermOutlook         Outlook `json:"longTermOutlook"`
+}
+
+// Outlook is a technical outlook over a single time horizon. Direction is "Bullish", "Bearish" or "Neutral".
+type Outlook struct {
+	StateDescription       string  `json:"stateDescription"`
+	Direction              string  `json:"direction"`
+	Score                  float64 `json:"score"`
+	ScoreDescription       string  `json:"scoreDescription"`
+	SectorDirection        string  `json:"sectorDirection"`
+	SectorScore            float64 `json:"sectorScore"`
+	SectorScoreDescription string  `json:"sectorScoreDescription"`
+	IndexDirection         string  `json:"indexDirection"`
+	IndexScore             float64 `json:"indexScore"`
+	IndexScoreDescription  string  `json:"indexScoreDescription"`
+}
+
+// KeyTechnicals contains the support, resistance and stop loss levels of an asset.
+type KeyTechnicals struct {
+	Provider   string  `json:"provider"`
+	Support    float64 `json:"support"`
+	Resistance float64 `json:"resistance"`
+	StopLoss   float64 `json:"stopLoss"`
+}
+
+// Valuation describes whether an asset is considered overvalued or undervalued.
+type Valuation struct {
+	Color         float64 `json:"color"`
+	Description   string  `json:"description"`
+	Discount      string  `json:"discount"`
+	Provider      string  `json:"provider"`
+	RelativeValue string  `json:"relativeValue"`
+}
+
+// CompanySnapshot compares a company's scores with those of its sector.
+type CompanySnapshot struct {
+	SectorInfo string         `json:"sectorInfo"`
+	Company    SnapshotScores `json:"company"`
+	Sector     SnapshotScores `json:"sector"`
+}
+
+// SnapshotScores are scores between 0 and 1.
+type SnapshotScores struct {
+	Innovativeness    float64 `json:"innovativeness"`
+	Hiring            float64 `json:"hiring"`
+	Sustainability    float64 `json:"sustainability"`
+	InsiderSentiments float64 `json:"insiderSentiments"`
+	EarningsReports   float64 `json:"earningsReports"`
+	Dividends         float64 `json:"dividends"`
+}
+
+// InsightRecommendation is an analyst rating and price target.
+type InsightRecommendation struct {
+	TargetPrice float64 `json:"targetPrice"`
+	Provider    string  `json:"provider"`
+	Rating      string  `json:"rating"`
+}
+
+// ResearchReport summarizes a research report about an asset.
+type ResearchReport struct {
+	Id                string  `json:"id"`
+	HeadHtml          string  `json:"headHtml"`
+	Provider          string  `json:"provider"`
+	ReportDate        string  `json:"reportDate"`
+	ReportTitle       string  `json:"reportTitle"`
+	ReportType        string  `json:"reportType"`
+	TargetPrice       float64 `json:"targetPrice"`
+	TargetPriceStatus string  `json:"targetPriceStatus"`
+	InvestmentRating  string  `json:"investmentRating"`
+}
+
+// SignificantDevelopment is a headline about a recent event affecting an asset.
+type SignificantDevelopment struct {
+	Headline string `json:"headline"`
+	Date     string `json:"date"`
+}
+
+// GetInsights retrieves the technical outlooks, valuation, research reports and significant developments for symbol.
+func (c *Client) GetInsights(symbol string) (Insights, error) {
+	var v outerInsightsResp
+	err := c.getJSON(INSIGHTS+"?symbol="+url.QueryEscape(symbol), &v)
+	if err != nil {
+		return Insights{}, err
+	}
+	if v.Finance.Result == nil {
+		return Insights{}, ErrMalformedResp
+	}
+	return *v.Finance.Result, nil
+}
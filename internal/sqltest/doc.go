@@ -0,0 +1,6 @@
+// Package sqltest tests yfi's SQLiteSink against a real SQLite database. It is a separate module so that
+// importers of yfi do not depend on a database driver, which means that go test ./... in the root module
+// does not run it. Run it from this directory:
+//
+//	cd internal/sqltest && go test ./...
+package sqltest
@@ -0,0 +1,126 @@
+package sqltest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cdillond/yfi"
+	_ "modernc.org/sqlite"
+)
+
+func TestSQLiteSink(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1) // each connection to :memory: is a separate database
+	ctx := context.Background()
+
+	// an older version of the quotes table that lacks most columns
+	if _, err = db.Exec(`CREATE TABLE "yfi_quotes" ("symbol" TEXT NOT NULL, "regular_market_time" BIGINT NOT NULL, PRIMARY KEY ("symbol", "regular_market_time"))`); err != nil {
+		t.Fatal(err)
+	}
+	s := yfi.NewSQLiteSink(db)
+	for i := 0; i < 2; i++ {
+		if err = s.Migrate(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	bars := []yfi.Bar{
+		{Time: 1696267800, Open: 171.22, High: 174.3, Low: 170.93, Close: 173.75, AdjClose: 173.04, Volume: 52164500},
+		{Time: 1696354200, Open: 172.26, High: 173.63, Low: 170.82, Close: 172.4, AdjClose: 171.7, Volume: 49594600},
+	}
+	if err = s.WriteTickers(ctx, yfi.TickerFromBars("AAPL", yfi.OneDay, bars)); err != nil {
+		t.Fatal(err)
+	}
+	bars[1].AdjClose = 171.5
+	bars = append(bars, yfi.Bar{Time: 1696440600, Open: 171.09, High: 174.21, Low: 170.97, Close: 173.66, AdjClose: 173.66, Volume: 53020300})
+	if err = s.WriteTickers(ctx, yfi.TickerFromBars("AAPL", yfi.OneDay, bars[1:]), yfi.TickerFromBars("MSFT", yfi.OneDay, bars[:1])); err != nil {
+		t.Fatal(err)
+	}
+	var n int
+	var adj float64
+	if err = db.QueryRow(`SELECT COUNT(*) FROM yfi_bars WHERE symbol = 'AAPL'`).Scan(&n); err != nil || n != 3 {
+		t.Errorf("expected 3 AAPL bars, got %d, %v", n, err)
+	}
+	if err = db.QueryRow(`SELECT adj_close FROM yfi_bars WHERE symbol = 'AAPL' AND "time" = 1696354200`).Scan(&adj); err != nil || adj != 171.5 {
+		t.Errorf("expected the upserted adj_close 171.5, got %v, %v", adj, err)
+	}
+
+	quotes := []yfi.Quote{
+		{Symbol: "AAPL", RegularMarketPrice: 173.75, MarketState: yfi.MarketRegular, RegularMarketTime: 1696276800, Tradeable: true, AverageDailyVolume10Day: 56000000},
+		{Symbol: "AAPL", RegularMarketPrice: 172.4, MarketState: yfi.MarketClosed, RegularMarketTime: 1696363200},
+	}
+	if err = s.WriteQuotes(ctx, quotes...); err != nil {
+		t.Fatal(err)
+	}
+	quotes[1].RegularMarketPrice = 172.41
+	if err = s.WriteQuotes(ctx, quotes[1]); err != nil {
+		t.Fatal(err)
+	}
+	var price float64
+	var state string
+	var vol int64
+	var tradeable bool
+	if err = db.QueryRow(`SELECT COUNT(*) FROM yfi_quotes`).Scan(&n); err != nil || n != 2 {
+		t.Errorf("expected 2 quotes, got %d, %v", n, err)
+	}
+	if err = db.QueryRow(`SELECT regular_market_price, market_state FROM yfi_quotes ORDER BY regular_market_time DESC`).Scan(&price, &state); err != nil || price != 172.41 || state != string(yfi.MarketClosed) {
+		t.Errorf("unexpected latest quote %v %v, %v", price, state, err)
+	}
+	if err = db.QueryRow(`SELECT average_daily_volume_10_day, tradeable FROM yfi_quotes ORDER BY regular_market_time`).Scan(&vol, &tradeable); err != nil || vol != 56000000 || !tradeable {
+		t.Errorf("unexpected first quote %v %v, %v", vol, tradeable, err)
+	}
+
+	asOf := time.Date(2023, 9, 30, 0, 0, 0, 0, time.UTC)
+	f := yfi.Fundamentals{Symbol: "AAPL", Series: map[string]yfi.FundamentalsSeries{
+		yfi.FundamentalsKey(yfi.Annual, yfi.TotalRevenue): {Freq: yfi.Annual, Item: yfi.TotalRevenue, Points: []yfi.FundamentalsPoint{
+			{AsOfDate: asOf.AddDate(-1, 0, 0), PeriodType: "12M", CurrencyCode: "USD", ReportedValue: yfi.Value{Raw: 394328000000, Valid: true}},
+			{AsOfDate: asOf, PeriodType: "12M", CurrencyCode: "USD"},
+		}},
+	}}
+	if err = s.WriteFundamentals(ctx, f); err != nil {
+		t.Fatal(err)
+	}
+	var rev sql.NullFloat64
+	if err = db.QueryRow(`SELECT value FROM yfi_fundamentals WHERE item = 'TotalRevenue' AND as_of_date = '2023-09-30'`).Scan(&rev); err != nil || rev.Valid {
+		t.Errorf("expected a null value, got %v, %v", rev, err)
+	}
+	if err = db.QueryRow(`SELECT value FROM yfi_fundamentals WHERE freq = 'annual' AND as_of_date = '2022-09-30'`).Scan(&rev); err != nil || rev.Float64 != 394328000000 {
+		t.Errorf("unexpected revenue %v, %v", rev, err)
+	}
+
+	esg := yfi.EsgScoresModule{TotalEsg: yfi.Value{Raw: 17.2, Valid: true}, RatingYear: 2023, RatingMonth: 9, PeerGroup: "Technology Hardware"}
+	if err = s.WriteEsgScores(ctx, "AAPL", esg); err != nil {
+		t.Fatal(err)
+	}
+	esg.TotalEsg.Raw = 17.3
+	if err = s.WriteEsgScores(ctx, "AAPL", esg); err != nil {
+		t.Fatal(err)
+	}
+	if err = db.QueryRow(`SELECT COUNT(*), MAX(total_esg) FROM yfi_esg_scores`).Scan(&n, &price); err != nil || n != 1 || price != 17.3 {
+		t.Errorf("unexpected esg scores %d %v, %v", n, price, err)
+	}
+
+	cal := yfi.Calendar{Events: []yfi.CalendarEvent{
+		{Symbol: "AAPL", Type: yfi.EarningsEvent, Date: time.Date(2023, 11, 2, 20, 30, 0, 0, time.UTC), TimeType: "AMC", EarningsAverage: yfi.Value{Raw: 1.39, Valid: true}},
+		{Symbol: "AAPL", Type: yfi.ExDividendEvent, Date: time.Date(2023, 11, 10, 0, 0, 0, 0, time.UTC)},
+	}}
+	if err = s.WriteCalendar(ctx, cal); err != nil {
+		t.Fatal(err)
+	}
+	var eps sql.NullFloat64
+	var end sql.NullString
+	if err = db.QueryRow(`SELECT eps_estimate, end_date FROM yfi_calendar_events WHERE "type" = 'earnings' AND "date" = '2023-11-02'`).Scan(&eps, &end); err != nil || eps.Float64 != 1.39 || end.Valid {
+		t.Errorf("unexpected earnings event %v %v, %v", eps, end, err)
+	}
+
+	if err = s.WriteTickers(ctx, yfi.Ticker{Symbol: "X", HistoricDates: []int64{1}}); !errors.Is(err, yfi.ErrColumnLength) {
+		t.Errorf("expected ErrColumnLength, got %v", err)
+	}
+}
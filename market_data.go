@@ -57,31 +57,31 @@ type markSumResp struct {
 
 // MarketSummary represents the current state of a particular exchange
 type MarketSummary struct {
-	FullExchangeName            string   `json:"fullExchangeName"`
-	Symbol                      string   `json:"symbol"`
-	GmtOffSetMilliseconds       int64    `json:"gmtOffSetMilliseconds"`
-	RegularMarketTime           yfiTime  `json:"regularMarketTime"`
-	RegularMarketChangePercent  yfiFloat `json:"regularMarketChangePercent"`
-	QuoteType                   string   `json:"quoteType"`
-	TypeDisp                    string   `json:"typeDisp"`
-	Tradeable                   bool     `json:"tradeable"`
-	RegularMarketPreviousClose  yfiFloat `json:"regularMarketPreviousClose"`
-	RegularMarketChange         yfiFloat `json:"regularMarketChange"`
-	CryptoTradeable             bool     `json:"cryptoTradeable"`
-	FirstTradeDateMilliseconds  int64    `json:"firstTradeDateMilliseconds"`
-	ExchangeDataDelayedBy       int64    `json:"exchangeDataDelayedBy"`
-	ExchangeTimezoneShortName   string   `json:"exchangeTimezoneShortName"`
-	CustomePriceAlertConfidence string   `json:"customePriceAlertConfidence"`
-	RegularMarketPrice          yfiFloat `json:"regularMarketPrice"`
-	MarketState                 string   `json:"marketState"`
-	Market                      string   `json:"market"`
-	QuoteSourceName             string   `json:"quoteSourceName"`
-	PriceHint                   int64    `json:"priceHint"`
-	Exchange                    string   `json:"exchange"`
-	SourceInterval              int64    `json:"sourceInterval"`
-	ShortName                   string   `json:"shortName"`
-	Region                      string   `json:"region"`
-	Triggerable                 bool     `json:"triggerable"`
+	FullExchangeName            string      `json:"fullExchangeName"`
+	Symbol                      string      `json:"symbol"`
+	GmtOffSetMilliseconds       int64       `json:"gmtOffSetMilliseconds"`
+	RegularMarketTime           Value       `json:"regularMarketTime"`
+	RegularMarketChangePercent  Value       `json:"regularMarketChangePercent"`
+	QuoteType                   string      `json:"quoteType"`
+	TypeDisp                    string      `json:"typeDisp"`
+	Tradeable                   bool        `json:"tradeable"`
+	RegularMarketPreviousClose  Value       `json:"regularMarketPreviousClose"`
+	RegularMarketChange         Value       `json:"regularMarketChange"`
+	CryptoTradeable             bool        `json:"cryptoTradeable"`
+	FirstTradeDateMilliseconds  int64       `json:"firstTradeDateMilliseconds"`
+	ExchangeDataDelayedBy       int64       `json:"exchangeDataDelayedBy"`
+	ExchangeTimezoneShortName   string      `json:"exchangeTimezoneShortName"`
+	CustomePriceAlertConfidence string      `json:"customePriceAlertConfidence"`
+	RegularMarketPrice          Value       `json:"regularMarketPrice"`
+	MarketState                 MarketState `json:"marketState"`
+	Market                      string      `json:"market"`
+	QuoteSourceName             string      `json:"quoteSourceName"`
+	PriceHint                   int64       `json:"priceHint"`
+	Exchange                    string      `json:"exchange"`
+	SourceInterval              int64       `json:"sourceInterval"`
+	ShortName                   string      `json:"shortName"`
+	Region                      string      `json:"region"`
+	Triggerable                 bool        `json:"triggerable"`
 }
 
 func (c *Client) GetMarketsSummary() ([]MarketSummary, error) {
@@ -0,0 +1,163 @@
+package yfi
+
+import (
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// MarketState is the trading session an exchange is in, as reported by Quote and MarketSummary.
+type MarketState string
+
+const (
+	MarketPrePre   MarketState = "PREPRE"
+	MarketPre      MarketState = "PRE"
+	MarketRegular  MarketState = "REGULAR"
+	MarketPost     MarketState = "POST"
+	MarketPostPost MarketState = "POSTPOST"
+	MarketClosed   MarketState = "CLOSED"
+)
+
+// IsOpen reports whether the regular trading session is in progress.
+func (s MarketState) IsOpen() bool {
+	return s == MarketRegular
+}
+
+// IsExtendedHours reports whether the pre-market or after-hours session is in progress.
+func (s MarketState) IsExtendedHours() bool {
+	return s == MarketPre || s == MarketPost
+}
+
+// IsTrading reports whether any trading session, regular or extended, is in progress.
+func (s MarketState) IsTrading() bool {
+	return s.IsOpen() || s.IsExtendedHours()
+}
+
+// Valid reports whether s is one of the known MarketState values.
+func (s MarketState) Valid() bool {
+	switch s {
+	case MarketPrePre, MarketPre, MarketRegular, MarketPost, MarketPostPost, MarketClosed:
+		return true
+	}
+	return false
+}
+
+type outerMarketTimeResp struct {
+	Finance marketTimeResp `json:"finance"`
+}
+
+type marketTimeResp struct {
+	MarketTimes []marketTimes `json:"marketTimes"`
+	Error       any           `json:"error"`
+}
+
+type marketTimes struct {
+	Id         string          `json:"id"`
+	Name       string          `json:"name"`
+	MarketTime []rawMarketTime `json:"marketTime"`
+}
+
+type rawMarketTime struct {
+	Id       string `json:"id"`
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Message  string `json:"message"`
+	Open     string `json:"open"`
+	Close    string `json:"close"`
+	Time     string `json:"time"`
+	Timezone []struct {
+		Dst       string `json:"dst"`
+		GmtOffset string `json:"gmtoffset"`
+		Short     string `json:"short"`
+		Name      string `json:"$text"`
+	} `json:"timezone"`
+}
+
+// MarketTime contains the regular session hours of a market. Open and Close refer to the
+// current session, or to the most recent one if the market is closed.
+type MarketTime struct {
+	Id            string
+	Name          string
+	Status        string
+	Message       string
+	Open          time.Time
+	Close         time.Time
+	Time          time.Time
+	Timezone      *time.Location
+	TimezoneShort string
+	DST           bool
+}
+
+// GetMarketTime retrieves the trading hours of the market in region, e.g. "US" or "GB".
+func (c *Client) GetMarketTime(region string) (MarketTime, error) {
+	var res MarketTime
+	var v outerMarketTimeResp
+	err := c.getJSON(V6+"markettime?formatted=true&lang=en-US&region="+url.QueryEscape(region), &v)
+	if err != nil {
+		return res, err
+	}
+	if len(v.Finance.MarketTimes) == 0 || len(v.Finance.MarketTimes[0].MarketTime) == 0 {
+		return res, ErrMalformedResp
+	}
+	raw := v.Finance.MarketTimes[0].MarketTime[0]
+	res.Id = raw.Id
+	res.Name = raw.Name
+	res.Status = raw.Status
+	res.Message = raw.Message
+	res.Timezone = time.UTC
+	if len(raw.Timezone) > 0 {
+		tz := raw.Timezone[0]
+		res.TimezoneShort = tz.Short
+		res.DST = tz.Dst == "true"
+		if loc, err := time.LoadLocation(tz.Name); err == nil {
+			res.Timezone = loc
+		} else if ms, err := strconv.Atoi(tz.GmtOffset); err == nil {
+			res.Timezone = time.FixedZone(tz.Short, ms/1000)
+		}
+	}
+	if res.Open, err = time.Parse(time.RFC3339, raw.Open); err != nil {
+		return res, ErrMalformedResp
+	}
+	if res.Close, err = time.Parse(time.RFC3339, raw.Close); err != nil {
+		return res, ErrMalformedResp
+	}
+	if res.Time, err = time.Parse(time.RFC3339, raw.Time); err != nil {
+		return res, ErrMalformedResp
+	}
+	res.Open = res.Open.In(res.Timezone)
+	res.Close = res.Close.In(res.Timezone)
+	res.Time = res.Time.In(res.Timezone)
+	return res, nil
+}
+
+// IsOpen reports whether now falls within the regular session.
+func (m MarketTime) IsOpen(now time.Time) bool {
+	return !now.Before(m.Open) && now.Before(m.Close)
+}
+
+// NextOpen returns the first session open after now. Sessions are assumed to recur at the same
+// local time every weekday; exchange holidays are not taken into account.
+func (m MarketTime) NextOpen(now time.Time) time.Time {
+	return m.next(m.Open, now)
+}
+
+// NextClose returns the first session close after now, with the same assumptions as NextOpen.
+func (m MarketTime) NextClose(now time.Time) time.Time {
+	return m.next(m.Close, now)
+}
+
+func (m MarketTime) next(t, now time.Time) time.Time {
+	loc := m.Timezone
+	if loc == nil {
+		loc = time.UTC
+	}
+	t = t.In(loc)
+	// step back first so that sessions earlier than the reference one are found too
+	for t.After(now) {
+		t = t.AddDate(0, 0, -1)
+	}
+	for !t.After(now) || t.Weekday() == time.Saturday || t.Weekday() == time.Sunday {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t
+}
@@ -0,0 +1,286 @@
+package yfi
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"time"
+)
+
+// OptionType distinguishes call and put contracts.
+type OptionType int
+
+const (
+	Call OptionType = iota
+	Put
+)
+
+func (t OptionType) String() string {
+	if t == Put {
+		return "put"
+	}
+	return "call"
+}
+
+// MarshalText encodes t as "call" or "put".
+func (t OptionType) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// UnmarshalText decodes "call" or "put" into t.
+func (t *OptionType) UnmarshalText(b []byte) error {
+	switch string(b) {
+	case "call":
+		*t = Call
+	case "put":
+		*t = Put
+	default:
+		return errors.New("invalid option type " + strconv.Quote(string(b)))
+	}
+	return nil
+}
+
+// Model is an option pricing model.
+type Model int
+
+const (
+	// BlackScholes prices options on a spot asset paying a continuous dividend yield.
+	BlackScholes Model = iota
+	// Black76 prices options on futures or forwards. OptionParams.Underlying is the futures price
+	// and OptionParams.DividendYield is ignored.
+	Black76
+)
+
+// OptionParams contains the inputs of an option pricing model.
+// Rates, yields and volatilities are annualized and continuously compounded, e.g. 0.05 for 5%.
+type OptionParams struct {
+	Type          OptionType
+	Underlying    float64
+	Strike        float64
+	Expiry        float64 // time to expiration in years
+	Rate          float64
+	DividendYield float64
+	Volatility    float64
+}
+
+// Greeks contains the sensitivities of an option's price. Vega and Rho are per 1.00 change
+// in volatility and rate, and Theta is per year; divide by 100 and 365 respectively for the
+// more common per-point and per-day figures.
+type Greeks struct {
+	Delta float64
+	Gamma float64
+	Vega  float64
+	Theta float64
+	Rho   float64
+}
+
+const (
+	minVolatility = 1e-6
+	maxVolatility = 5.0
+	ivTolerance   = 1e-8
+	ivIterations  = 100
+)
+
+// carry returns the cost of carry of the underlying asset under m.
+func (m Model) carry(p OptionParams) float64 {
+	if m == Black76 {
+		return 0
+	}
+	return p.Rate - p.DividendYield
+}
+
+func normCDF(x float64) float64 {
+	return 0.5 * math.Erfc(-x/math.Sqrt2)
+}
+
+func normPDF(x float64) float64 {
+	return math.Exp(-0.5*x*x) / math.Sqrt(2*math.Pi)
+}
+
+func (m Model) d1d2(p OptionParams) (float64, float64) {
+	sqrtT := math.Sqrt(p.Expiry)
+	d1 := (math.Log(p.Underlying/p.Strike) + (m.carry(p)+0.5*p.Volatility*p.Volatility)*p.Expiry) / (p.Volatility * sqrtT)
+	return d1, d1 - p.Volatility*sqrtT
+}
+
+// expired reports whether p has no time value left to price.
+func expired(p OptionParams) bool {
+	return p.Expiry <= 0 || p.Volatility <= 0
+}
+
+func intrinsic(p OptionParams) float64 {
+	if p.Type == Put {
+		return math.Max(p.Strike-p.Underlying, 0)
+	}
+	return math.Max(p.Underlying-p.Strike, 0)
+}
+
+// Price returns the theoretical value of an option. Options that have expired
+// or have no volatility are valued at their intrinsic value.
+func (m Model) Price(p OptionParams) float64 {
+	if expired(p) {
+		return intrinsic(p)
+	}
+	d1, d2 := m.d1d2(p)
+	carryDisc := math.Exp((m.carry(p) - p.Rate) * p.Expiry)
+	disc := math.Exp(-p.Rate * p.Expiry)
+	if p.Type == Put {
+		return p.Strike*disc*normCDF(-d2) - p.Underlying*carryDisc*normCDF(-d1)
+	}
+	return p.Underlying*carryDisc*normCDF(d1) - p.Strike*disc*normCDF(d2)
+}
+
+// Greeks returns the sensitivities of an option's price to its inputs.
+func (m Model) Greeks(p OptionParams) Greeks {
+	var res Greeks
+	if expired(p) {
+		if intrinsic(p) > 0 {
+			res.Delta = 1
+			if p.Type == Put {
+				res.Delta = -1
+			}
+		}
+		return res
+	}
+	b := m.carry(p)
+	sqrtT := math.Sqrt(p.Expiry)
+	d1, d2 := m.d1d2(p)
+	carryDisc := math.Exp((b - p.Rate) * p.Expiry)
+	disc := math.Exp(-p.Rate * p.Expiry)
+	pdf := normPDF(d1)
+
+	res.Gamma = carryDisc * pdf / (p.Underlying * p.Volatility * sqrtT)
+	res.Vega = p.Underlying * carryDisc * pdf * sqrtT
+	decay := -p.Underlying * carryDisc * pdf * p.Volatility / (2 * sqrtT)
+	if p.Type == Put {
+		res.Delta = carryDisc * (normCDF(d1) - 1)
+		res.Theta = decay + (b-p.Rate)*p.Underlying*carryDisc*normCDF(-d1) + p.Rate*p.Strike*disc*normCDF(-d2)
+		res.Rho = -p.Expiry * p.Strike * disc * normCDF(-d2)
+	} else {
+		res.Delta = carryDisc * normCDF(d1)
+		res.Theta = decay - (b-p.Rate)*p.Underlying*carryDisc*normCDF(d1) - p.Rate*p.Strike*disc*normCDF(d2)
+		res.Rho = p.Expiry * p.Strike * disc * normCDF(d2)
+	}
+	if m == Black76 {
+		// the futures price does not depend on the rate, so only discounting is affected
+		res.Rho = -p.Expiry * m.Price(p)
+	}
+	return res
+}
+
+// ImpliedVolatility solves for the volatility at which the model price of an option equals price.
+// p.Volatility is ignored. ErrImpliedVol is returned if price is outside the range of possible model prices.
+func (m Model) ImpliedVolatility(p OptionParams, price float64) (float64, error) {
+	if p.Expiry <= 0 || p.Underlying <= 0 || p.Strike <= 0 || price <= 0 {
+		return 0, ErrImpliedVol
+	}
+	lo, hi := minVolatility, maxVolatility
+	p.Volatility = lo
+	if price < m.Price(p) {
+		return 0, ErrImpliedVol
+	}
+	p.Volatility = hi
+	if price > m.Price(p) {
+		return 0, ErrImpliedVol
+	}
+
+	// Newton's method, falling back to bisection whenever a step leaves the bracket
+	vol := 0.3
+	for i := 0; i < ivIterations; i++ {
+		p.Volatility = vol
+		diff := m.Price(p) - price
+		if math.Abs(diff) < ivTolerance {
+			return vol, nil
+		}
+		if diff > 0 {
+			hi = vol
+		} else {
+			lo = vol
+		}
+		vega := m.Greeks(p).Vega
+		next := vol - diff/vega
+		if vega <= 0 || next <= lo || next >= hi || math.IsNaN(next) {
+			next = (lo + hi) / 2
+		}
+		vol = next
+		if hi-lo < ivTolerance {
+			return vol, nil
+		}
+	}
+	return vol, nil
+}
+
+// Type returns whether the contract is a call or a put, based on its OCC-style contract symbol.
+func (o OptionContract) Type() OptionType {
+	if n := len(o.ContractSymbol); n >= 9 && o.ContractSymbol[n-9] == 'P' {
+		return Put
+	}
+	return Call
+}
+
+// Mid returns the midpoint of the contract's bid and ask, or its LastPrice if either is unavailable.
+func (o OptionContract) Mid() float64 {
+	if o.Bid <= 0 || o.Ask <= 0 {
+		return o.LastPrice
+	}
+	return (o.Bid + o.Ask) / 2
+}
+
+// YearsToExpiry returns the time remaining between now and the contract's expiration in years.
+func (o OptionContract) YearsToExpiry(now time.Time) float64 {
+	return o.ExpirationTime().Sub(now).Hours() / (24 * 365)
+}
+
+// Params returns the OptionParams of the contract for the given underlying price, rate and dividend yield.
+// The Volatility is set to the contract's ImpliedVolatility as reported by Yahoo.
+func (o OptionContract) Params(underlying, rate, dividendYield float64, now time.Time) OptionParams {
+	return OptionParams{
+		Type:          o.Type(),
+		Underlying:    underlying,
+		Strike:        o.Strike,
+		Expiry:        o.YearsToExpiry(now),
+		Rate:          rate,
+		DividendYield: dividendYield,
+		Volatility:    o.ImpliedVolatility,
+	}
+}
+
+// SolveImpliedVolatility solves for the contract's implied volatility from its Mid price.
+// This can be used to cross-check the ImpliedVolatility field reported by Yahoo.
+func (o OptionContract) SolveImpliedVolatility(m Model, underlying, rate, dividendYield float64, now time.Time) (float64, error) {
+	return m.ImpliedVolatility(o.Params(underlying, rate, dividendYield, now), o.Mid())
+}
+
+// OptionPosition is a holding of Quantity contracts; negative quantities represent short positions.
+// A Multiplier of 0 is treated as the standard 100 shares per contract, and a Volatility of 0
+// means the contract's ImpliedVolatility is used.
+type OptionPosition struct {
+	Contract   OptionContract
+	Quantity   float64
+	Multiplier float64
+	Volatility float64
+}
+
+// PortfolioExposure returns the sum of the Greeks of each position, scaled by quantity and multiplier,
+// for a single underlying asset. The resulting Delta is the share-equivalent exposure of the portfolio.
+func PortfolioExposure(m Model, positions []OptionPosition, underlying, rate, dividendYield float64, now time.Time) Greeks {
+	var res Greeks
+	for _, pos := range positions {
+		p := pos.Contract.Params(underlying, rate, dividendYield, now)
+		if pos.Volatility > 0 {
+			p.Volatility = pos.Volatility
+		}
+		mult := pos.Multiplier
+		if mult == 0 {
+			mult = 100
+		}
+		g := m.Greeks(p)
+		scale := pos.Quantity * mult
+		res.Delta += g.Delta * scale
+		res.Gamma += g.Gamma * scale
+		res.Vega += g.Vega * scale
+		res.Theta += g.Theta * scale
+		res.Rho += g.Rho * scale
+	}
+	return res
+}
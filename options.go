@@ -0,0 +1,141 @@
+package yfi
+
+import (
+	"log"
+	"strconv"
+	"time"
+)
+
+type outerOptionResp struct {
+	OptionChain optionResp `json:"optionChain"`
+}
+
+type optionResp struct {
+	Result []optionResult `json:"result"`
+	Error  any            `json:"error"`
+}
+
+type optionResult struct {
+	UnderlyingSymbol string             `json:"underlyingSymbol"`
+	ExpirationDates  []int64            `json:"expirationDates"`
+	Strikes          []float64          `json:"strikes"`
+	HasMiniOptions   bool               `json:"hasMiniOptions"`
+	Quote            Quote              `json:"quote"`
+	Options          []optionExpiration `json:"options"`
+}
+
+type optionExpiration struct {
+	ExpirationDate int64            `json:"expirationDate"`
+	HasMiniOptions bool             `json:"hasMiniOptions"`
+	Calls          []OptionContract `json:"calls"`
+	Puts           []OptionContract `json:"puts"`
+}
+
+// OptionContract represents a single call or put contract.
+type OptionContract struct {
+	ContractSymbol    string  `json:"contractSymbol"`
+	Strike            float64 `json:"strike"`
+	Currency          string  `json:"currency"`
+	LastPrice         float64 `json:"lastPrice"`
+	Change            float64 `json:"change"`
+	PercentChange     float64 `json:"percentChange"`
+	Volume            int     `json:"volume"`
+	OpenInterest      int     `json:"openInterest"`
+	Bid               float64 `json:"bid"`
+	Ask               float64 `json:"ask"`
+	ContractSize      string  `json:"contractSize"`
+	Expiration        int64   `json:"expiration"`
+	LastTradeDate     int64   `json:"lastTradeDate"`
+	ImpliedVolatility float64 `json:"impliedVolatility"`
+	InTheMoney        bool    `json:"inTheMoney"`
+}
+
+// ExpirationTime returns the contract's expiration date.
+func (o OptionContract) ExpirationTime() time.Time {
+	return time.Unix(o.Expiration, 0)
+}
+
+// LastTradeTime returns the time of the contract's most recent trade.
+func (o OptionContract) LastTradeTime() time.Time {
+	return time.Unix(o.LastTradeDate, 0)
+}
+
+// OptionChain contains the calls and puts for a single expiration date of an underlying asset.
+// ExpirationDates and Strikes list every expiration date and strike price available for the underlying asset.
+type OptionChain struct {
+	UnderlyingSymbol string
+	ExpirationDates  []int64
+	Strikes          []float64
+	HasMiniOptions   bool
+	Quote            Quote
+	ExpirationDate   int64
+	Calls            []OptionContract
+	Puts             []OptionContract
+}
+
+// Expirations returns the ExpirationDates as a slice of time.Time values.
+func (o OptionChain) Expirations() []time.Time {
+	res := make([]time.Time, len(o.ExpirationDates))
+	for i := 0; i < len(o.ExpirationDates); i++ {
+		res[i] = time.Unix(o.ExpirationDates[i], 0)
+	}
+	return res
+}
+
+// GetOptionChain retrieves the option chain of symbol for the given expiration date.
+// If expiration is the zero time.Time, the chain for the nearest expiration date is returned.
+// Yahoo identifies expiration dates by midnight UTC; expiration should match one of the chain's ExpirationDates.
+func (c *Client) GetOptionChain(symbol string, expiration time.Time) (OptionChain, error) {
+	var res OptionChain
+	url := V7 + "options/" + symbol
+	if !expiration.IsZero() {
+		url += "?date=" + strconv.Itoa(int(expiration.Unix()))
+	}
+	var v outerOptionResp
+	err := c.getJSON(url, &v)
+	if err != nil {
+		return res, err
+	}
+	if len(v.OptionChain.Result) == 0 {
+		return res, ErrMalformedResp
+	}
+	r := v.OptionChain.Result[0]
+	res.UnderlyingSymbol = r.UnderlyingSymbol
+	res.ExpirationDates = r.ExpirationDates
+	res.Strikes = r.Strikes
+	res.HasMiniOptions = r.HasMiniOptions
+	res.Quote = r.Quote
+	if len(r.Options) > 0 {
+		res.ExpirationDate = r.Options[0].ExpirationDate
+		res.Calls = r.Options[0].Calls
+		res.Puts = r.Options[0].Puts
+	}
+	return res, nil
+}
+
+// GetOptionChains retrieves the option chain of symbol for every available expiration date.
+// Each request is followed by a WaitPeriod to reduce the risk of rate limiting.
+// If a request fails, the chains retrieved so far are returned along with the error.
+func (c *Client) GetOptionChains(symbol string) ([]OptionChain, error) {
+	first, err := c.GetOptionChain(symbol, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	res := make([]OptionChain, 0, len(first.ExpirationDates))
+	for _, exp := range first.ExpirationDates {
+		if exp == first.ExpirationDate {
+			res = append(res, first)
+			continue
+		}
+		time.Sleep(c.WaitPeriod)
+		chain, err := c.GetOptionChain(symbol, time.Unix(exp, 0))
+		if c.Verbose {
+			log.Println(symbol, exp, err)
+		}
+		if err != nil {
+			return res, err
+		}
+		res = append(res, chain)
+	}
+	return res, nil
+}
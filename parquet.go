@@ -0,0 +1,1241 @@
+package yfi
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// This file implements the subset of Apache Parquet needed to exchange Ticker and Quote data with analytics
+// tools such as DuckDB and Spark. Files are written with flat schemas of required or optional columns, stored
+// in a single row group of uncompressed, PLAIN-encoded version 1 data pages. The reader also accepts the
+// files those tools write by default: any physical type, version 1 or 2 data pages, PLAIN or dictionary
+// encoding and uncompressed, Snappy or GZIP pages. ZSTD compression, DELTA encodings and nested columns
+// are rejected with ErrParquet.
+
+const (
+	pqMagic = "PAR1"
+
+	// physical types
+	pqBoolean           = 0
+	pqInt32             = 1
+	pqInt64             = 2
+	pqInt96             = 3
+	pqFloat             = 4
+	pqDouble            = 5
+	pqByteArray         = 6
+	pqFixedLenByteArray = 7
+
+	// encodings
+	pqPlain           = 0
+	pqPlainDictionary = 2
+	pqRLE             = 3
+	pqRLEDictionary   = 8
+
+	// compression codecs
+	pqUncompressed = 0
+	pqSnappy       = 1
+	pqGzip         = 2
+
+	// page types
+	pqDataPage       = 0
+	pqDictionaryPage = 2
+	pqDataPageV2     = 3
+
+	// thrift compact protocol types
+	tcTrue   = 1
+	tcFalse  = 2
+	tcByte   = 3
+	tcI16    = 4
+	tcI32    = 5
+	tcI64    = 6
+	tcDouble = 7
+	tcBinary = 8
+	tcList   = 9
+	tcSet    = 10
+	tcMap    = 11
+	tcStruct = 12
+)
+
+// pqLogical is the logical type annotating a column.
+type pqLogical int
+
+const (
+	pqNone pqLogical = iota
+	pqString
+	pqTimestampMillis
+)
+
+// pqColumn holds the values of a column. The slice matching typ holds one value per row; for optional
+// columns, valid marks the rows that are not null and the values of null rows are zero.
+type pqColumn struct {
+	name     string
+	typ      int32
+	logical  pqLogical
+	optional bool
+	valid    []bool
+	ints     []int64
+	floats   []float64
+	strs     []string
+	bools    []bool
+
+	// set by readParquet
+	length  int  // size of FIXED_LEN_BYTE_ARRAY values
+	decimal bool // whether the values are decimals with scale digits after the point
+	scale   int
+	unit    int64 // nanoseconds per unit of timestamps and dates
+}
+
+func (c *pqColumn) len() int {
+	switch c.typ {
+	case pqBoolean:
+		return len(c.bools)
+	case pqInt32, pqInt64, pqInt96:
+		return len(c.ints)
+	case pqFloat, pqDouble:
+		return len(c.floats)
+	}
+	return len(c.strs)
+}
+
+// thriftWriter encodes structs with the thrift compact protocol used by Parquet metadata.
+type thriftWriter struct {
+	buf  []byte
+	last []int16 // id of the previous field of each enclosing struct
+}
+
+func (t *thriftWriter) uvarint(v uint64) {
+	t.buf = binary.AppendUvarint(t.buf, v)
+}
+
+func (t *thriftWriter) varint(v int64) {
+	t.uvarint(uint64(v<<1) ^ uint64(v>>63)) // zigzag encoding
+}
+
+func (t *thriftWriter) field(id int16, typ byte) {
+	last := int16(0)
+	if n := len(t.last); n > 0 {
+		last = t.last[n-1]
+		t.last[n-1] = id
+	}
+	if d := id - last; d > 0 && d <= 15 {
+		t.buf = append(t.buf, byte(d)<<4|typ)
+		return
+	}
+	t.buf = append(t.buf, typ)
+	t.varint(int64(id))
+}
+
+func (t *thriftWriter) i32(id int16, v int32) {
+	t.field(id, tcI32)
+	t.varint(int64(v))
+}
+
+func (t *thriftWriter) i64(id int16, v int64) {
+	t.field(id, tcI64)
+	t.varint(v)
+}
+
+func (t *thriftWriter) bool(id int16, v bool) {
+	if v {
+		t.field(id, tcTrue)
+	} else {
+		t.field(id, tcFalse)
+	}
+}
+
+func (t *thriftWriter) binary(id int16, b []byte) {
+	t.field(id, tcBinary)
+	t.uvarint(uint64(len(b)))
+	t.buf = append(t.buf, b...)
+}
+
+func (t *thriftWriter) list(id int16, elemType byte, n int) {
+	t.field(id, tcList)
+	if n < 15 {
+		t.buf = append(t.buf, byte(n)<<4|elemType)
+		return
+	}
+	t.buf = append(t.buf, 0xF0|elemType)
+	t.uvarint(uint64(n))
+}
+
+// begin starts a struct; if id is positive, the struct is a field of the enclosing struct, otherwise a list element.
+func (t *thriftWriter) begin(id int16) {
+	if id > 0 {
+		t.field(id, tcStruct)
+	}
+	t.last = append(t.last, 0)
+}
+
+func (t *thriftWriter) end() {
+	t.buf = append(t.buf, 0) // stop
+	t.last = t.last[:len(t.last)-1]
+}
+
+// thriftReader decodes thrift compact structs into maps of field ids to values. Integers are decoded
+// as int64, binaries as []byte, lists and sets as []any and structs as map[int16]any.
+type thriftReader struct {
+	b   []byte
+	pos int
+}
+
+func (r *thriftReader) byte() (byte, error) {
+	if r.pos >= len(r.b) {
+		return 0, ErrParquet
+	}
+	r.pos++
+	return r.b[r.pos-1], nil
+}
+
+func (r *thriftReader) uvarint() (uint64, error) {
+	v, n := binary.Uvarint(r.b[r.pos:])
+	if n <= 0 {
+		return 0, ErrParquet
+	}
+	r.pos += n
+	return v, nil
+}
+
+func (r *thriftReader) varint() (int64, error) {
+	v, err := r.uvarint()
+	return int64(v>>1) ^ -int64(v&1), err
+}
+
+func (r *thriftReader) value(typ byte, depth int) (any, error) {
+	if depth > 32 {
+		return nil, ErrParquet
+	}
+	switch typ {
+	case tcTrue, tcFalse:
+		b, err := r.byte()
+		return b == tcTrue, err
+	case tcByte:
+		b, err := r.byte()
+		return int64(int8(b)), err
+	case tcI16, tcI32, tcI64:
+		return r.varint()
+	case tcDouble:
+		if len(r.b)-r.pos < 8 {
+			return nil, ErrParquet
+		}
+		r.pos += 8
+		return math.Float64frombits(binary.LittleEndian.Uint64(r.b[r.pos-8:])), nil
+	case tcBinary:
+		n, err := r.uvarint()
+		if err != nil || n > uint64(len(r.b)-r.pos) {
+			return nil, ErrParquet
+		}
+		r.pos += int(n)
+		return r.b[r.pos-int(n) : r.pos], nil
+	case tcList, tcSet:
+		h, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		n := uint64(h >> 4)
+		if n == 15 {
+			if n, err = r.uvarint(); err != nil {
+				return nil, err
+			}
+		}
+		if n > uint64(len(r.b)-r.pos) {
+			return nil, ErrParquet
+		}
+		res := make([]any, n)
+		for i := range res {
+			if res[i], err = r.value(h&0x0F, depth+1); err != nil {
+				return nil, err
+			}
+		}
+		return res, nil
+	case tcMap:
+		n, err := r.uvarint()
+		if err != nil || n == 0 {
+			return nil, err
+		}
+		if n > uint64(len(r.b)-r.pos) {
+			return nil, ErrParquet
+		}
+		kv, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		res := make([]any, 0, 2*n)
+		for i := uint64(0); i < 2*n; i++ {
+			typ := kv >> 4
+			if i%2 == 1 {
+				typ = kv & 0x0F
+			}
+			v, err := r.value(typ, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, v)
+		}
+		return res, nil
+	case tcStruct:
+		return r.structure(depth + 1)
+	}
+	return nil, ErrParquet
+}
+
+func (r *thriftReader) structure(depth int) (map[int16]any, error) {
+	res := make(map[int16]any)
+	last := int16(0)
+	for {
+		h, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		if h == 0 {
+			return res, nil
+		}
+		id := last + int16(h>>4)
+		if h>>4 == 0 {
+			v, err := r.varint()
+			if err != nil {
+				return nil, err
+			}
+			id = int16(v)
+		}
+		last = id
+		typ := h & 0x0F
+		if typ == tcTrue || typ == tcFalse {
+			res[id] = typ == tcTrue
+			continue
+		}
+		if res[id], err = r.value(typ, depth); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func tInt(m map[int16]any, id int16) (int64, bool) {
+	v, ok := m[id].(int64)
+	return v, ok
+}
+
+func tStruct(m map[int16]any, id int16) map[int16]any {
+	v, _ := m[id].(map[int16]any)
+	return v
+}
+
+func tList(m map[int16]any, id int16) []any {
+	v, _ := m[id].([]any)
+	return v
+}
+
+// appendRLE appends the definition levels of valid, encoded as runs of the RLE/bit-packing hybrid
+// encoding with a bit width of 1.
+func appendRLE(b []byte, valid []bool) []byte {
+	for i := 0; i < len(valid); {
+		j := i
+		for j < len(valid) && valid[j] == valid[i] {
+			j++
+		}
+		b = binary.AppendUvarint(b, uint64(j-i)<<1)
+		if valid[i] {
+			b = append(b, 1)
+		} else {
+			b = append(b, 0)
+		}
+		i = j
+	}
+	return b
+}
+
+// decodeHybrid decodes n values with the given bit width from the RLE/bit-packing hybrid encoding.
+func decodeHybrid(b []byte, width, n int) ([]uint32, error) {
+	if width > 32 {
+		return nil, ErrParquet
+	}
+	res := make([]uint32, 0, n)
+	for len(res) < n {
+		h, k := binary.Uvarint(b)
+		if k <= 0 {
+			return nil, ErrParquet
+		}
+		b = b[k:]
+		if h&1 == 0 { // run of a repeated value, stored in as few bytes as the width allows
+			w := (width + 7) / 8
+			if len(b) < w {
+				return nil, ErrParquet
+			}
+			var v uint32
+			for i := w - 1; i >= 0; i-- {
+				v = v<<8 | uint32(b[i])
+			}
+			b = b[w:]
+			for i := uint64(0); i < h>>1 && len(res) < n; i++ {
+				res = append(res, v)
+			}
+			continue
+		}
+		groups := h >> 1 // bit-packed groups of 8 values, each taking width bytes
+		if width > 0 && groups > uint64(len(b)/width) {
+			return nil, ErrParquet
+		}
+		var acc uint64
+		bits, pos := 0, 0
+		for i := uint64(0); i < groups*8 && len(res) < n; i++ {
+			for bits < width {
+				acc |= uint64(b[pos]) << bits
+				pos++
+				bits += 8
+			}
+			res = append(res, uint32(acc&(1<<width-1)))
+			acc >>= width
+			bits -= width
+		}
+		b = b[int(groups)*width:]
+	}
+	return res, nil
+}
+
+// decodeLevels decodes n definition levels with a bit width of 1 from the RLE/bit-packing hybrid encoding.
+func decodeLevels(b []byte, n int) ([]bool, error) {
+	levels, err := decodeHybrid(b, 1, n)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]bool, n)
+	for i, l := range levels {
+		res[i] = l != 0
+	}
+	return res, nil
+}
+
+// page encodes the values of c as a PLAIN data page, preceded by definition levels if c is optional.
+func (c *pqColumn) page() []byte {
+	var b []byte
+	n := c.len()
+	valid := func(i int) bool { return !c.optional || c.valid[i] }
+	if c.optional {
+		levels := appendRLE(nil, c.valid)
+		b = binary.LittleEndian.AppendUint32(b, uint32(len(levels)))
+		b = append(b, levels...)
+	}
+	switch c.typ {
+	case pqBoolean:
+		var bits []byte
+		k := 0
+		for i := 0; i < n; i++ {
+			if !valid(i) {
+				continue
+			}
+			if k%8 == 0 {
+				bits = append(bits, 0)
+			}
+			if c.bools[i] {
+				bits[k/8] |= 1 << (k % 8)
+			}
+			k++
+		}
+		b = append(b, bits...)
+	case pqInt64:
+		for i := 0; i < n; i++ {
+			if valid(i) {
+				b = binary.LittleEndian.AppendUint64(b, uint64(c.ints[i]))
+			}
+		}
+	case pqDouble:
+		for i := 0; i < n; i++ {
+			if valid(i) {
+				b = binary.LittleEndian.AppendUint64(b, math.Float64bits(c.floats[i]))
+			}
+		}
+	case pqByteArray:
+		for i := 0; i < n; i++ {
+			if valid(i) {
+				b = binary.LittleEndian.AppendUint32(b, uint32(len(c.strs[i])))
+				b = append(b, c.strs[i]...)
+			}
+		}
+	}
+	return b
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(b []byte) (int, error) {
+	n, err := cw.w.Write(b)
+	cw.n += int64(n)
+	return n, err
+}
+
+// writeParquet writes cols, which must all have numRows values, to w as a Parquet file with a single row group.
+func writeParquet(w io.Writer, cols []*pqColumn, numRows int) error {
+	cw := &countingWriter{w: w}
+	if _, err := io.WriteString(cw, pqMagic); err != nil {
+		return err
+	}
+	offsets := make([]int64, len(cols))
+	sizes := make([]int64, len(cols))
+	for i, c := range cols {
+		data := c.page()
+		var h thriftWriter
+		h.begin(0)
+		h.i32(1, 0) // DATA_PAGE
+		h.i32(2, int32(len(data)))
+		h.i32(3, int32(len(data)))
+		h.begin(5)
+		h.i32(1, int32(numRows))
+		h.i32(2, pqPlain)
+		h.i32(3, pqRLE)
+		h.i32(4, pqRLE)
+		h.end()
+		h.end()
+		offsets[i] = cw.n
+		sizes[i] = int64(len(h.buf) + len(data))
+		if _, err := cw.Write(h.buf); err != nil {
+			return err
+		}
+		if _, err := cw.Write(data); err != nil {
+			return err
+		}
+	}
+
+	var m thriftWriter
+	m.begin(0)
+	m.i32(1, 1) // version
+	m.list(2, tcStruct, len(cols)+1)
+	m.begin(0)
+	m.binary(4, []byte("schema"))
+	m.i32(5, int32(len(cols)))
+	m.end()
+	for _, c := range cols {
+		m.begin(0)
+		m.i32(1, c.typ)
+		if c.optional {
+			m.i32(3, 1)
+		} else {
+			m.i32(3, 0)
+		}
+		m.binary(4, []byte(c.name))
+		switch c.logical {
+		case pqString:
+			m.i32(6, 0) // UTF8
+			m.begin(10)
+			m.begin(1)
+			m.end()
+			m.end()
+		case pqTimestampMillis:
+			m.i32(6, 9) // TIMESTAMP_MILLIS
+			m.begin(10)
+			m.begin(8)
+			m.bool(1, true) // adjusted to UTC
+			m.begin(2)
+			m.begin(1) // MILLIS
+			m.end()
+			m.end()
+			m.end()
+			m.end()
+		}
+		m.end()
+	}
+	m.i64(3, int64(numRows))
+	m.list(4, tcStruct, 1)
+	m.begin(0)
+	m.list(1, tcStruct, len(cols))
+	var total int64
+	for i, c := range cols {
+		m.begin(0)
+		m.i64(2, offsets[i])
+		m.begin(3)
+		m.i32(1, c.typ)
+		m.list(2, tcI32, 2)
+		m.varint(pqPlain)
+		m.varint(pqRLE)
+		m.list(3, tcBinary, 1)
+		m.uvarint(uint64(len(c.name)))
+		m.buf = append(m.buf, c.name...)
+		m.i32(4, 0) // UNCOMPRESSED
+		m.i64(5, int64(numRows))
+		m.i64(6, sizes[i])
+		m.i64(7, sizes[i])
+		m.i64(9, offsets[i])
+		m.end()
+		m.end()
+		total += sizes[i]
+	}
+	m.i64(2, total)
+	m.i64(3, int64(numRows))
+	m.end()
+	m.binary(6, []byte("yfi"))
+	m.end()
+
+	if _, err := cw.Write(m.buf); err != nil {
+		return err
+	}
+	tail := binary.LittleEndian.AppendUint32(nil, uint32(len(m.buf)))
+	_, err := cw.Write(append(tail, pqMagic...))
+	return err
+}
+
+// readParquet reads the flat columns of the Parquet file r of the given size. INT32 and INT96 columns are
+// returned as INT64, FLOAT and decimal columns as DOUBLE and FIXED_LEN_BYTE_ARRAY columns as BYTE_ARRAY.
+// Timestamps and dates are converted to milliseconds.
+func readParquet(r io.ReaderAt, size int64) (int, map[string]*pqColumn, error) {
+	if size < 12 {
+		return 0, nil, ErrParquet
+	}
+	tail := make([]byte, 8)
+	if _, err := r.ReadAt(tail, size-8); err != nil {
+		return 0, nil, err
+	}
+	footerLen := int64(binary.LittleEndian.Uint32(tail))
+	if string(tail[4:]) != pqMagic || footerLen > size-12 {
+		return 0, nil, ErrParquet
+	}
+	footer := make([]byte, footerLen)
+	if _, err := r.ReadAt(footer, size-8-footerLen); err != nil {
+		return 0, nil, err
+	}
+	tr := thriftReader{b: footer}
+	meta, err := tr.structure(0)
+	if err != nil {
+		return 0, nil, err
+	}
+	numRows, _ := tInt(meta, 3)
+	if numRows < 0 || numRows > size*8 { // even booleans take a bit per row
+		return 0, nil, ErrParquet
+	}
+
+	cols := make(map[string]*pqColumn)
+	schema := tList(meta, 2)
+	if len(schema) == 0 {
+		return 0, nil, ErrParquet
+	}
+	for _, e := range schema[1:] {
+		el, _ := e.(map[int16]any)
+		name, _ := el[4].([]byte)
+		typ, ok := tInt(el, 1)
+		if n, _ := tInt(el, 5); n > 0 || !ok || typ < pqBoolean || typ > pqFixedLenByteArray {
+			return 0, nil, ErrParquet // nested schemas are not supported
+		}
+		rep, _ := tInt(el, 3)
+		if rep > 1 {
+			return 0, nil, ErrParquet
+		}
+		length, _ := tInt(el, 2)
+		c := &pqColumn{name: string(name), typ: int32(typ), optional: rep == 1, length: int(length)}
+		if typ == pqFixedLenByteArray && (length <= 0 || length > size) {
+			return 0, nil, ErrParquet
+		}
+		conv, hasConv := tInt(el, 6)
+		scale, _ := tInt(el, 7)
+		switch {
+		case hasConv && (conv == 0 || conv == 4): // UTF8, ENUM
+			c.logical = pqString
+		case hasConv && conv == 5: // DECIMAL
+			c.decimal, c.scale = true, int(scale)
+		case hasConv && conv == 6: // DATE
+			c.logical, c.unit = pqTimestampMillis, 86400e9
+		case hasConv && conv == 9: // TIMESTAMP_MILLIS
+			c.logical, c.unit = pqTimestampMillis, 1e6
+		case hasConv && conv == 10: // TIMESTAMP_MICROS
+			c.logical, c.unit = pqTimestampMillis, 1e3
+		}
+		if lt := tStruct(el, 10); lt != nil {
+			switch {
+			case tStruct(lt, 1) != nil, tStruct(lt, 4) != nil, tStruct(lt, 12) != nil: // STRING, ENUM, JSON
+				c.logical = pqString
+			case tStruct(lt, 5) != nil:
+				scale, _ := tInt(tStruct(lt, 5), 1)
+				c.decimal, c.scale = true, int(scale)
+			case tStruct(lt, 6) != nil:
+				c.logical, c.unit = pqTimestampMillis, 86400e9
+			case tStruct(lt, 8) != nil:
+				unit := tStruct(tStruct(lt, 8), 2)
+				c.logical = pqTimestampMillis
+				switch {
+				case tStruct(unit, 2) != nil:
+					c.unit = 1e3
+				case tStruct(unit, 3) != nil:
+					c.unit = 1
+				default:
+					c.unit = 1e6
+				}
+			}
+		}
+		if typ == pqInt96 { // legacy timestamps, which are decoded to milliseconds directly
+			c.logical, c.unit = pqTimestampMillis, 0
+		}
+		if c.decimal && (c.scale < 0 || c.scale > 76 || typ == pqBoolean || typ == pqInt96 || typ == pqFloat || typ == pqDouble) {
+			return 0, nil, ErrParquet
+		}
+		cols[c.name] = c
+	}
+
+	for _, g := range tList(meta, 4) {
+		rg, _ := g.(map[int16]any)
+		for _, ch := range tList(rg, 1) {
+			cc, _ := ch.(map[int16]any)
+			cm := tStruct(cc, 3)
+			path := tList(cm, 3)
+			if cm == nil || len(path) != 1 {
+				return 0, nil, ErrParquet
+			}
+			name, _ := path[0].([]byte)
+			c, ok := cols[string(name)]
+			if !ok {
+				return 0, nil, ErrParquet
+			}
+			codec, _ := tInt(cm, 4)
+			start, _ := tInt(cm, 9)
+			// the dictionary page, if any, precedes the data pages
+			if dict, ok := tInt(cm, 11); ok && dict >= 4 && dict < start {
+				start = dict
+			}
+			length, _ := tInt(cm, 7)
+			values, _ := tInt(cm, 5)
+			if start < 4 || length < 0 || start+length > size-8-footerLen || values < 0 || values > numRows {
+				return 0, nil, ErrParquet
+			}
+			chunk := make([]byte, length)
+			if _, err := r.ReadAt(chunk, start); err != nil {
+				return 0, nil, err
+			}
+			if err := c.readChunk(chunk, int(values), codec); err != nil {
+				return 0, nil, err
+			}
+		}
+	}
+	for _, c := range cols {
+		if c.len() != int(numRows) {
+			return 0, nil, ErrParquet
+		}
+		if err := c.normalize(); err != nil {
+			return 0, nil, err
+		}
+	}
+	return int(numRows), cols, nil
+}
+
+// decompress decompresses the page data b, which has the given size once decompressed.
+func decompress(codec int64, b []byte, size int64) ([]byte, error) {
+	var res []byte
+	var err error
+	switch codec {
+	case pqUncompressed:
+		res = b
+	case pqSnappy:
+		res, err = snappyDecode(b)
+	case pqGzip:
+		var zr *gzip.Reader
+		if zr, err = gzip.NewReader(bytes.NewReader(b)); err != nil {
+			return nil, ErrParquet
+		}
+		res, err = io.ReadAll(io.LimitReader(zr, size+1))
+	default:
+		return nil, ErrParquet
+	}
+	if err != nil || int64(len(res)) != size {
+		return nil, ErrParquet
+	}
+	return res, nil
+}
+
+// readChunk decodes the pages of a column chunk containing n values and appends them to c.
+func (c *pqColumn) readChunk(chunk []byte, n int, codec int64) error {
+	var dict *pqColumn
+	read := 0
+	for read < n {
+		tr := thriftReader{b: chunk}
+		h, err := tr.structure(0)
+		if err != nil {
+			return err
+		}
+		usize, _ := tInt(h, 2)
+		size, _ := tInt(h, 3)
+		if size < 0 || size > int64(len(chunk)-tr.pos) || usize < 0 {
+			return ErrParquet
+		}
+		data := chunk[tr.pos : tr.pos+int(size)]
+		chunk = chunk[tr.pos+int(size):]
+
+		var valid []bool
+		var count, enc int64
+		switch typ, _ := tInt(h, 1); typ {
+		case pqDictionaryPage:
+			dh := tStruct(h, 7)
+			count, _ = tInt(dh, 1)
+			if enc, _ = tInt(dh, 2); (enc != pqPlain && enc != pqPlainDictionary) || count < 0 || count > usize*8 {
+				return ErrParquet
+			}
+			if data, err = decompress(codec, data, usize); err != nil {
+				return err
+			}
+			dict = &pqColumn{typ: c.typ, length: c.length}
+			if _, err = dict.appendPlain(data, int(count)); err != nil {
+				return err
+			}
+			continue
+		case pqDataPage:
+			dp := tStruct(h, 5)
+			count, _ = tInt(dp, 1)
+			enc, _ = tInt(dp, 2)
+			if count < 0 || count > int64(n-read) {
+				return ErrParquet
+			}
+			if data, err = decompress(codec, data, usize); err != nil {
+				return err
+			}
+			if c.optional {
+				if len(data) < 4 || uint64(binary.LittleEndian.Uint32(data)) > uint64(len(data)-4) {
+					return ErrParquet
+				}
+				l := binary.LittleEndian.Uint32(data)
+				if valid, err = decodeLevels(data[4:4+l], int(count)); err != nil {
+					return err
+				}
+				data = data[4+l:]
+			}
+		case pqDataPageV2:
+			dp := tStruct(h, 8)
+			count, _ = tInt(dp, 1)
+			enc, _ = tInt(dp, 4)
+			defLen, _ := tInt(dp, 5)
+			repLen, _ := tInt(dp, 6)
+			if count < 0 || count > int64(n-read) || repLen != 0 || defLen < 0 || defLen > int64(len(data)) || defLen > usize {
+				return ErrParquet
+			}
+			if c.optional {
+				if valid, err = decodeLevels(data[:defLen], int(count)); err != nil {
+					return err
+				}
+			}
+			data = data[defLen:]
+			// the levels are never compressed, and the values only if is_compressed is unset or true
+			if compressed, ok := dp[7].(bool); !ok || compressed {
+				if data, err = decompress(codec, data, usize-defLen); err != nil {
+					return err
+				}
+			}
+		default:
+			continue
+		}
+		if err = c.readValues(data, int(count), enc, valid, dict); err != nil {
+			return err
+		}
+		read += int(count)
+	}
+	return nil
+}
+
+// readValues appends the n values of a data page with the given encoding to c. valid holds the
+// definition levels of optional columns, in which case only the values of non-null rows are stored in b.
+func (c *pqColumn) readValues(b []byte, n int, enc int64, valid []bool, dict *pqColumn) error {
+	m := n
+	if c.optional {
+		m = 0
+		for _, ok := range valid {
+			if ok {
+				m++
+			}
+		}
+	}
+	src := &pqColumn{typ: c.typ, length: c.length}
+	switch enc {
+	case pqPlain:
+		if _, err := src.appendPlain(b, m); err != nil {
+			return err
+		}
+	case pqPlainDictionary, pqRLEDictionary:
+		if m == 0 {
+			break
+		}
+		if dict == nil || len(b) < 1 {
+			return ErrParquet
+		}
+		idx, err := decodeHybrid(b[1:], int(b[0]), m)
+		if err != nil {
+			return err
+		}
+		for _, i := range idx {
+			if int(i) >= dict.len() {
+				return ErrParquet
+			}
+			src.appendFrom(dict, int(i))
+		}
+	case pqRLE:
+		if c.typ != pqBoolean || len(b) < 4 || uint64(binary.LittleEndian.Uint32(b)) > uint64(len(b)-4) {
+			return ErrParquet
+		}
+		bits, err := decodeHybrid(b[4:4+binary.LittleEndian.Uint32(b)], 1, m)
+		if err != nil {
+			return err
+		}
+		for _, v := range bits {
+			src.bools = append(src.bools, v != 0)
+		}
+	default:
+		return ErrParquet
+	}
+	if !c.optional {
+		for i := 0; i < n; i++ {
+			c.appendFrom(src, i)
+		}
+		return nil
+	}
+	c.valid = append(c.valid, valid...)
+	k := 0 // index of the next non-null value
+	for _, ok := range valid {
+		if ok {
+			c.appendFrom(src, k)
+			k++
+		} else {
+			c.appendFrom(nil, 0)
+		}
+	}
+	return nil
+}
+
+// appendFrom appends the i-th value of src to c, or the zero value if src is nil.
+func (c *pqColumn) appendFrom(src *pqColumn, i int) {
+	switch c.typ {
+	case pqBoolean:
+		c.bools = append(c.bools, src != nil && src.bools[i])
+	case pqInt32, pqInt64, pqInt96:
+		var v int64
+		if src != nil {
+			v = src.ints[i]
+		}
+		c.ints = append(c.ints, v)
+	case pqFloat, pqDouble:
+		var v float64
+		if src != nil {
+			v = src.floats[i]
+		}
+		c.floats = append(c.floats, v)
+	default:
+		var v string
+		if src != nil {
+			v = src.strs[i]
+		}
+		c.strs = append(c.strs, v)
+	}
+}
+
+// appendPlain appends n PLAIN-encoded values from b to c and returns the rest of b.
+func (c *pqColumn) appendPlain(b []byte, n int) ([]byte, error) {
+	size := map[int32]int{pqInt32: 4, pqInt64: 8, pqInt96: 12, pqFloat: 4, pqDouble: 8, pqFixedLenByteArray: c.length}[c.typ]
+	if size > 0 && n > len(b)/size {
+		return nil, ErrParquet
+	}
+	for i := 0; i < n; i++ {
+		switch c.typ {
+		case pqBoolean:
+			if i/8 >= len(b) {
+				return nil, ErrParquet
+			}
+			c.bools = append(c.bools, b[i/8]>>(i%8)&1 != 0)
+			continue
+		case pqInt32:
+			c.ints = append(c.ints, int64(int32(binary.LittleEndian.Uint32(b))))
+		case pqInt64:
+			c.ints = append(c.ints, int64(binary.LittleEndian.Uint64(b)))
+		case pqInt96: // nanoseconds of the day followed by the Julian day
+			nanos := int64(binary.LittleEndian.Uint64(b))
+			day := int64(int32(binary.LittleEndian.Uint32(b[8:])))
+			c.ints = append(c.ints, (day-2440588)*86400000+nanos/1e6)
+		case pqFloat:
+			c.floats = append(c.floats, float64(math.Float32frombits(binary.LittleEndian.Uint32(b))))
+		case pqDouble:
+			c.floats = append(c.floats, math.Float64frombits(binary.LittleEndian.Uint64(b)))
+		case pqByteArray:
+			if len(b) < 4 || uint64(binary.LittleEndian.Uint32(b)) > uint64(len(b)-4) {
+				return nil, ErrParquet
+			}
+			l := int(binary.LittleEndian.Uint32(b))
+			c.strs = append(c.strs, string(b[4:4+l]))
+			b = b[4+l:]
+			continue
+		case pqFixedLenByteArray:
+			c.strs = append(c.strs, string(b[:size]))
+		}
+		b = b[size:]
+	}
+	if c.typ == pqBoolean {
+		b = b[(n+7)/8:]
+	}
+	return b, nil
+}
+
+// normalize converts the values of c to the types documented by readParquet.
+func (c *pqColumn) normalize() error {
+	if c.decimal {
+		c.floats = make([]float64, c.len())
+		for i := range c.floats {
+			var digits string
+			if c.typ == pqInt32 || c.typ == pqInt64 {
+				digits = strconv.FormatInt(c.ints[i], 10)
+			} else { // big-endian two's complement
+				b := []byte(c.strs[i])
+				v := new(big.Int).SetBytes(b)
+				if len(b) > 0 && b[0]&0x80 != 0 {
+					v.Sub(v, new(big.Int).Lsh(big.NewInt(1), uint(8*len(b))))
+				}
+				digits = v.String()
+			}
+			f, err := strconv.ParseFloat(digits+"e-"+strconv.Itoa(c.scale), 64)
+			if err != nil {
+				return ErrParquet
+			}
+			c.floats[i] = f
+		}
+		c.typ, c.ints, c.strs = pqDouble, nil, nil
+		return nil
+	}
+	switch c.typ {
+	case pqInt32, pqInt96:
+		c.typ = pqInt64
+	case pqFloat:
+		c.typ = pqDouble
+	case pqFixedLenByteArray:
+		c.typ = pqByteArray
+	}
+	switch {
+	case c.unit >= 1e6:
+		for i := range c.ints {
+			c.ints[i] *= c.unit / 1e6
+		}
+	case c.unit > 0:
+		d := 1e6 / c.unit
+		for i, v := range c.ints {
+			c.ints[i] = v / d
+			if v%d < 0 {
+				c.ints[i]--
+			}
+		}
+	}
+	return nil
+}
+
+// pqLookup returns the column of cols with the given name and physical type. INT64 columns are converted to DOUBLE if necessary.
+func pqLookup(cols map[string]*pqColumn, name string, typ int32) (*pqColumn, error) {
+	c, ok := cols[name]
+	if ok && c.typ == pqInt64 && typ == pqDouble && c.logical == pqNone {
+		c.floats = make([]float64, len(c.ints))
+		for i, v := range c.ints {
+			c.floats[i] = float64(v)
+		}
+		c.typ, c.ints = pqDouble, nil
+	}
+	if !ok || c.typ != typ {
+		return nil, ErrParquet
+	}
+	return c, nil
+}
+
+// WriteParquet writes the history of t to w as a Parquet file. See WriteTickersParquet.
+func (t *Ticker) WriteParquet(w io.Writer) error {
+	return WriteTickersParquet(w, []Ticker{*t})
+}
+
+// WriteTickersParquet writes the histories of tickers to w as a Parquet file with one row per bar and the columns
+// symbol, interval, time (a UTC timestamp with millisecond precision), open, high, low, close, adj_close and volume.
+func WriteTickersParquet(w io.Writer, tickers []Ticker) error {
+	cols := []*pqColumn{
+		{name: "symbol", typ: pqByteArray, logical: pqString},
+		{name: "interval", typ: pqByteArray, logical: pqString},
+		{name: "time", typ: pqInt64, logical: pqTimestampMillis},
+		{name: "open", typ: pqDouble},
+		{name: "high", typ: pqDouble},
+		{name: "low", typ: pqDouble},
+		{name: "close", typ: pqDouble},
+		{name: "adj_close", typ: pqDouble},
+		{name: "volume", typ: pqInt64},
+	}
+	n := 0
+	for i := range tickers {
+		t := &tickers[i]
+		if err := t.validate(); err != nil {
+			return err
+		}
+		for j := range t.HistoricDates {
+			cols[0].strs = append(cols[0].strs, t.Symbol)
+			cols[1].strs = append(cols[1].strs, string(t.Interval))
+			cols[2].ints = append(cols[2].ints, t.HistoricDates[j]*1000)
+			cols[3].floats = append(cols[3].floats, t.HistoricOpen[j])
+			cols[4].floats = append(cols[4].floats, t.HistoricHigh[j])
+			cols[5].floats = append(cols[5].floats, t.HistoricLow[j])
+			cols[6].floats = append(cols[6].floats, t.HistoricClose[j])
+			cols[7].floats = append(cols[7].floats, t.HistoricAdjClose[j])
+			cols[8].ints = append(cols[8].ints, int64(t.HistoricVolume[j]))
+		}
+		n += len(t.HistoricDates)
+	}
+	return writeParquet(w, cols, n)
+}
+
+// ReadTickersParquet reads a Parquet file with the columns written by WriteTickersParquet from r, which has the
+// given size. The file may also come from another tool such as DuckDB, as long as time is a timestamp or date column
+// and the other columns are numeric or strings as appropriate. Consecutive rows with the same symbol and interval
+// are returned as one Ticker. Null prices are read as NaN, null volumes as 0, and rows with a null time are skipped.
+func ReadTickersParquet(r io.ReaderAt, size int64) ([]Ticker, error) {
+	n, cols, err := readParquet(r, size)
+	if err != nil {
+		return nil, err
+	}
+	var c [9]*pqColumn
+	for i, name := range []string{"symbol", "interval", "time", "open", "high", "low", "close", "adj_close", "volume"} {
+		typ := int32(pqDouble)
+		switch i {
+		case 0, 1:
+			typ = pqByteArray
+		case 2, 8:
+			typ = pqInt64
+		}
+		if c[i], err = pqLookup(cols, name, typ); err != nil {
+			return nil, err
+		}
+	}
+	var res []Ticker
+	var t *Ticker
+	for i := 0; i < n; i++ {
+		if t == nil || t.Symbol != c[0].strs[i] || string(t.Interval) != c[1].strs[i] {
+			res = append(res, Ticker{Symbol: c[0].strs[i], Interval: TimeSpan(c[1].strs[i])})
+			t = &res[len(res)-1]
+		}
+		if c[2].optional && !c[2].valid[i] {
+			continue
+		}
+		ms := c[2].ints[i]
+		sec := ms / 1000
+		if ms%1000 < 0 {
+			sec--
+		}
+		t.HistoricDates = append(t.HistoricDates, sec)
+		t.HistoricOpen = append(t.HistoricOpen, c[3].float(i))
+		t.HistoricHigh = append(t.HistoricHigh, c[4].float(i))
+		t.HistoricLow = append(t.HistoricLow, c[5].float(i))
+		t.HistoricClose = append(t.HistoricClose, c[6].float(i))
+		t.HistoricAdjClose = append(t.HistoricAdjClose, c[7].float(i))
+		t.HistoricVolume = append(t.HistoricVolume, int(c[8].ints[i]))
+	}
+	return res, nil
+}
+
+// float returns the i-th value of the DOUBLE column c, or NaN if it is null.
+func (c *pqColumn) float(i int) float64 {
+	if c.optional && !c.valid[i] {
+		return math.NaN()
+	}
+	return c.floats[i]
+}
+
+// quoteTimestamps are the Quote fields that hold times, and the factor converting them to milliseconds.
+var quoteTimestamps = map[string]int64{
+	"regularMarketTime":          1000,
+	"postMarketTime":             1000,
+	"firstTradeDateMilliseconds": 1,
+}
+
+// quoteColumns returns a column for every field of Quote, named after its JSON key, along with the index of the field.
+func quoteColumns() ([]*pqColumn, []int) {
+	rt := reflect.TypeOf(Quote{})
+	var cols []*pqColumn
+	var fields []int
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		c := &pqColumn{name: name}
+		switch f.Type.Kind() {
+		case reflect.String:
+			c.typ, c.logical = pqByteArray, pqString
+		case reflect.Float64:
+			c.typ = pqDouble
+		case reflect.Int:
+			c.typ = pqInt64
+			if _, ok := quoteTimestamps[c.name]; ok {
+				c.logical, c.optional = pqTimestampMillis, true
+			}
+		case reflect.Bool:
+			c.typ = pqBoolean
+		default:
+			continue
+		}
+		cols = append(cols, c)
+		fields = append(fields, i)
+	}
+	return cols, fields
+}
+
+// WriteQuotesParquet writes quotes to w as a Parquet file with one row per Quote and one column per field,
+// named after its JSON key. regularMarketTime, postMarketTime and firstTradeDateMilliseconds are written as
+// UTC timestamps with millisecond precision, which are null when the field is 0.
+func WriteQuotesParquet(w io.Writer, quotes []Quote) error {
+	cols, fields := quoteColumns()
+	for _, q := range quotes {
+		v := reflect.ValueOf(q)
+		for i, c := range cols {
+			f := v.Field(fields[i])
+			switch c.typ {
+			case pqByteArray:
+				c.strs = append(c.strs, f.String())
+			case pqDouble:
+				c.floats = append(c.floats, f.Float())
+			case pqBoolean:
+				c.bools = append(c.bools, f.Bool())
+			case pqInt64:
+				x := f.Int()
+				if c.optional {
+					c.valid = append(c.valid, x != 0)
+					x *= quoteTimestamps[c.name]
+				}
+				c.ints = append(c.ints, x)
+			}
+		}
+	}
+	return writeParquet(w, cols, len(quotes))
+}
+
+// ReadQuotesParquet reads a Parquet file with the columns written by WriteQuotesParquet from r, which has the
+// given size. As with ReadTickersParquet, the file may come from another tool. Fields without a matching column,
+// or whose column is null, are left empty.
+func ReadQuotesParquet(r io.ReaderAt, size int64) ([]Quote, error) {
+	n, cols, err := readParquet(r, size)
+	if err != nil {
+		return nil, err
+	}
+	want, fields := quoteColumns()
+	res := make([]Quote, n)
+	for i, w := range want {
+		if _, ok := cols[w.name]; !ok {
+			continue
+		}
+		c, err := pqLookup(cols, w.name, w.typ)
+		if err != nil {
+			return nil, err
+		}
+		for j := range res {
+			f := reflect.ValueOf(&res[j]).Elem().Field(fields[i])
+			switch c.typ {
+			case pqByteArray:
+				f.SetString(c.strs[j])
+			case pqDouble:
+				f.SetFloat(c.floats[j])
+			case pqBoolean:
+				f.SetBool(c.bools[j])
+			case pqInt64:
+				x := c.ints[j]
+				if scale := quoteTimestamps[w.name]; scale > 1 {
+					x /= scale
+				}
+				f.SetInt(x)
+			}
+		}
+	}
+	return res, nil
+}
@@ -1,79 +1,135 @@
 package yfi
 
+import (
+	"sort"
+	"sync"
+)
+
 type QuoteParam string
 
 const (
-	AssetProfile                      QuoteParam = "assetProfile"
-	BalanceSheetHistory               QuoteParam = "balanceSheetHistory"
-	BalanceSheetHistoryQuarterly      QuoteParam = "balanceSheetHistoryQuarterly"
-	CalendarEvents                    QuoteParam = "calendarEvents"
-	CashflowStatementHistory          QuoteParam = "cashflowStatementHistory"
-	CashflowStatementHistoryQuarterly QuoteParam = "cashflowStatementHistoryQuarterly"
-	DefaultKeyStatistics              QuoteParam = "defaultKeyStatistics"
-	Earnings                          QuoteParam = "earnings"
-	EarningsHistory                   QuoteParam = "earningsHistory"
-	EarningsTrend                     QuoteParam = "earningsTrend"
-	EsgScores                         QuoteParam = "esgScores"
-	FinancialData                     QuoteParam = "financialData"
-	FundOwnership                     QuoteParam = "fundOwnership"
-	FundPerformance                   QuoteParam = "fundPerformance"
-	FundProfile                       QuoteParam = "fundProfile"
-	IndexTrend                        QuoteParam = "indexTrend"
-	IncomeStatementHistory            QuoteParam = "incomeStatementHistory"
-	IncomeStatementHistoryQuarterly   QuoteParam = "incomeStatementHistoryQuarterly"
-	IndustryTrend                     QuoteParam = "industryTrend"
-	InsiderHolders                    QuoteParam = "insiderHolders"
-	InstitutionOwnership              QuoteParam = "institutionOwnership"
-	MajorHoldersBreakdown             QuoteParam = "majorHoldersBreakdown"
-	PageViews                         QuoteParam = "pageViews"
-	Price                             QuoteParam = "price"
-	QuoteType                         QuoteParam = "quoteType"
-	RecommendationTrend               QuoteParam = "recommendationTrend"
-	SecFilings                        QuoteParam = "secFilings"
-	NetSharePurchaseActivity          QuoteParam = "netSharePurchaseActivity"
-	SectorTrend                       QuoteParam = "sectorTrend"
-	SummaryDetail                     QuoteParam = "summaryDetail"
-	SummaryProfile                    QuoteParam = "summaryProfile"
-	TopHoldings                       QuoteParam = "topHoldings"
-	UpgradeDowngradeHistory           QuoteParam = "upgradeDowngradeHistory"
+	AssetProfile                       QuoteParam = "assetProfile"
+	BalanceSheetHistory                QuoteParam = "balanceSheetHistory"
+	BalanceSheetHistoryQuarterly       QuoteParam = "balanceSheetHistoryQuarterly"
+	CalendarEvents                     QuoteParam = "calendarEvents"
+	CashflowStatementHistory           QuoteParam = "cashflowStatementHistory"
+	CashflowStatementHistoryQuarterly  QuoteParam = "cashflowStatementHistoryQuarterly"
+	DefaultKeyStatistics               QuoteParam = "defaultKeyStatistics"
+	Earnings                           QuoteParam = "earnings"
+	EarningsHistory                    QuoteParam = "earningsHistory"
+	EarningsTrend                      QuoteParam = "earningsTrend"
+	EsgScores                          QuoteParam = "esgScores"
+	FinancialData                      QuoteParam = "financialData"
+	FundOwnership                      QuoteParam = "fundOwnership"
+	FundPerformance                    QuoteParam = "fundPerformance"
+	FundProfile                        QuoteParam = "fundProfile"
+	IndexTrend                         QuoteParam = "indexTrend"
+	IncomeStatementHistory             QuoteParam = "incomeStatementHistory"
+	IncomeStatementHistoryQuarterly    QuoteParam = "incomeStatementHistoryQuarterly"
+	IndustryTrend                      QuoteParam = "industryTrend"
+	InsiderHolders                     QuoteParam = "insiderHolders"
+	InstitutionOwnership               QuoteParam = "institutionOwnership"
+	MajorHoldersBreakdown              QuoteParam = "majorHoldersBreakdown"
+	PageViews                          QuoteParam = "pageViews"
+	Price                              QuoteParam = "price"
+	QuoteType                          QuoteParam = "quoteType"
+	RecommendationTrend                QuoteParam = "recommendationTrend"
+	SecFilings                         QuoteParam = "secFilings"
+	NetSharePurchaseActivity           QuoteParam = "netSharePurchaseActivity"
+	SectorTrend                        QuoteParam = "sectorTrend"
+	SummaryDetail                      QuoteParam = "summaryDetail"
+	SummaryProfile                     QuoteParam = "summaryProfile"
+	TopHoldings                        QuoteParam = "topHoldings"
+	UpgradeDowngradeHistory            QuoteParam = "upgradeDowngradeHistory"
+	InsiderTransactions                QuoteParam = "insiderTransactions"
+	QuoteUnadjustedPerformanceOverview QuoteParam = "quoteUnadjustedPerformanceOverview"
+	EquityPerformance                  QuoteParam = "equityPerformance"
+	FinancialsTemplate                 QuoteParam = "financialsTemplate"
+	Components                         QuoteParam = "components"
+	FuturesChain                       QuoteParam = "futuresChain"
 )
 
+var (
+	registeredQuoteParamsMu sync.RWMutex
+	registeredQuoteParams   = map[QuoteParam]bool{
+		AssetProfile:                       true,
+		BalanceSheetHistory:                true,
+		BalanceSheetHistoryQuarterly:       true,
+		CalendarEvents:                     true,
+		CashflowStatementHistory:           true,
+		CashflowStatementHistoryQuarterly:  true,
+		DefaultKeyStatistics:               true,
+		Earnings:                           true,
+		EarningsHistory:                    true,
+		EarningsTrend:                      true,
+		EsgScores:                          true,
+		FinancialData:                      true,
+		FundOwnership:                      true,
+		FundPerformance:                    true,
+		FundProfile:                        true,
+		IndexTrend:                         true,
+		IncomeStatementHistory:             true,
+		IncomeStatementHistoryQuarterly:    true,
+		IndustryTrend:                      true,
+		InsiderHolders:                     true,
+		InstitutionOwnership:               true,
+		MajorHoldersBreakdown:              true,
+		PageViews:                          true,
+		Price:                              true,
+		QuoteType:                          true,
+		RecommendationTrend:                true,
+		SecFilings:                         true,
+		NetSharePurchaseActivity:           true,
+		SectorTrend:                        true,
+		SummaryDetail:                      true,
+		SummaryProfile:                     true,
+		TopHoldings:                        true,
+		UpgradeDowngradeHistory:            true,
+		InsiderTransactions:                true,
+		QuoteUnadjustedPerformanceOverview: true,
+		EquityPerformance:                  true,
+		FinancialsTemplate:                 true,
+		Components:                         true,
+		FuturesChain:                       true,
+	}
+)
+
+// RegisterQuoteParam adds q to the set of modules accepted by GetQuoteSummary.
+// This allows modules that Yahoo adds in the future to be requested before yfi defines a constant for them.
+func RegisterQuoteParam(q QuoteParam) {
+	if q == "" {
+		return
+	}
+	registeredQuoteParamsMu.Lock()
+	registeredQuoteParams[q] = true
+	registeredQuoteParamsMu.Unlock()
+}
+
+// unregisterQuoteParam removes q from the set of modules accepted by GetQuoteSummary.
+func unregisterQuoteParam(q QuoteParam) {
+	registeredQuoteParamsMu.Lock()
+	delete(registeredQuoteParams, q)
+	registeredQuoteParamsMu.Unlock()
+}
+
+// QuoteParams returns every registered QuoteParam in alphabetical order.
+func QuoteParams() []QuoteParam {
+	registeredQuoteParamsMu.RLock()
+	res := make([]QuoteParam, 0, len(registeredQuoteParams))
+	for q := range registeredQuoteParams {
+		res = append(res, q)
+	}
+	registeredQuoteParamsMu.RUnlock()
+	sort.Slice(res, func(i, j int) bool { return res[i] < res[j] })
+	return res
+}
+
 func validateQuoteParam(q QuoteParam) error {
-	switch q == AssetProfile ||
-		q == BalanceSheetHistory ||
-		q == BalanceSheetHistoryQuarterly ||
-		q == CalendarEvents ||
-		q == CashflowStatementHistory ||
-		q == CashflowStatementHistoryQuarterly ||
-		q == DefaultKeyStatistics ||
-		q == Earnings ||
-		q == EarningsHistory ||
-		q == EarningsTrend ||
-		q == EsgScores ||
-		q == FinancialData ||
-		q == FundOwnership ||
-		q == FundPerformance ||
-		q == FundProfile ||
-		q == IndexTrend ||
-		q == IncomeStatementHistory ||
-		q == IndustryTrend ||
-		q == InsiderHolders ||
-		q == InstitutionOwnership ||
-		q == MajorHoldersBreakdown ||
-		q == PageViews ||
-		q == Price ||
-		q == QuoteType ||
-		q == RecommendationTrend ||
-		q == SecFilings ||
-		q == NetSharePurchaseActivity ||
-		q == SectorTrend ||
-		q == SummaryDetail ||
-		q == SummaryProfile ||
-		q == TopHoldings ||
-		q == UpgradeDowngradeHistory {
-	case true:
-		return nil
-	default:
+	registeredQuoteParamsMu.RLock()
+	ok := registeredQuoteParams[q]
+	registeredQuoteParamsMu.RUnlock()
+	if !ok {
 		return ErrQuoteParam
 	}
+	return nil
 }
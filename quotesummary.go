@@ -4,80 +4,183 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"log"
 	"net/http"
+	"time"
 )
 
-func (c *Client) GetQuoteSummary(symbol string, quoteParams []QuoteParam) (map[string]any, error) {
-	res := make(map[string]any)
+// QuoteSummary contains the modules returned by the quoteSummary endpoint, keyed by QuoteParam.
+// Individual values can be retrieved with the path-based accessors (e.g. Float("financialData.currentPrice.raw"))
+// and entire modules can be decoded into typed structs with DecodeModule.
+type QuoteSummary map[string]any
+
+// GetQuoteSummary retrieves the modules corresponding to quoteParams for a given symbol.
+// Invalid quoteParams are ignored unless none are valid, in which case ErrQuoteParam is returned.
+// Use GetQuoteSummaryReport to find out which modules were invalid or missing.
+func (c *Client) GetQuoteSummary(symbol string, quoteParams []QuoteParam) (QuoteSummary, error) {
+	res, _, err := c.GetQuoteSummaryReport(symbol, quoteParams)
+	return res, err
+}
+
+// ModuleReport describes how each requested QuoteParam was handled by the quoteSummary endpoint.
+type ModuleReport struct {
+	// Invalid contains the quoteParams that are not registered and were not requested.
+	Invalid []QuoteParam
+	// Missing contains the quoteParams that were requested but are absent from the response.
+	Missing []QuoteParam
+	// Empty contains the quoteParams that were returned without any data.
+	Empty []QuoteParam
+}
+
+// Complete reports whether every requested module was valid and returned with data.
+func (r ModuleReport) Complete() bool {
+	return len(r.Invalid) == 0 && len(r.Missing) == 0 && len(r.Empty) == 0
+}
+
+// GetQuoteSummaryReport is like GetQuoteSummary, but also returns a ModuleReport
+// listing the requested modules that were invalid, missing or empty for this symbol.
+func (c *Client) GetQuoteSummaryReport(symbol string, quoteParams []QuoteParam) (QuoteSummary, ModuleReport, error) {
+	if c.Cache != nil && c.Cache.SummaryTTL > 0 {
+		return c.Cache.getQuoteSummaryReport(c, symbol, quoteParams)
+	}
+	res := make(QuoteSummary)
+	var report ModuleReport
 	if len(quoteParams) < 1 {
-		return res, ErrQuoteParam
+		return res, report, ErrQuoteParam
 	}
 	url := V10 + "quoteSummary/" + symbol + "?modules="
-	errs := make([]error, len(quoteParams))
-	err_count := 0
+	requested := make([]QuoteParam, 0, len(quoteParams))
 	for i := 0; i < len(quoteParams); i++ {
 		err := validateQuoteParam(quoteParams[i])
-		errs[i] = err
 		if err == nil {
 			url += string(quoteParams[i]) + ","
+			requested = append(requested, quoteParams[i])
 		} else {
-			err_count += 1
+			report.Invalid = append(report.Invalid, quoteParams[i])
 		}
 	}
-	url = url[:len(url)-1] // trim final comma
 
-	if err_count == len(quoteParams) {
-		return res, ErrQuoteParam
+	if len(requested) == 0 {
+		return res, report, ErrQuoteParam
 	}
+	url = url[:len(url)-1] // trim final comma
 
 	ctx, cancel := context.WithTimeout(context.Background(), c.TimeOut)
 	defer cancel()
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		return res, err
+		return res, report, err
 	}
 
 	resp, err := c.HttpClient.Do(req)
 	if err != nil {
-		return res, err
+		return res, report, err
 	}
+	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
 		switch {
 		case resp.StatusCode == http.StatusUnauthorized:
-			return res, ErrUnauthReq
+			return res, report, ErrUnauthReq
 		case resp.StatusCode == http.StatusNotFound:
-			return res, ErrNotFound
+			return res, report, ErrNotFound
 		default:
-			return res, errors.New("request error " + resp.Status)
+			return res, report, errors.New("request error " + resp.Status)
 		}
 	}
-	defer resp.Body.Close()
 	var v map[string]map[string]any
 	jdec := json.NewDecoder(resp.Body)
 	err = jdec.Decode(&v)
 	if err != nil {
-		return res, err
+		return res, report, err
 	}
 
 	layer1, ok := v["quoteSummary"]
 	if !ok {
-		return res, ErrMalformedResp
+		return res, report, ErrMalformedResp
 	}
 	layer2, ok := layer1["result"]
 	if !ok {
-		return res, ErrMalformedResp
+		return res, report, ErrMalformedResp
 	}
 	layer3, ok := layer2.([]any)
 	if !ok {
-		return res, ErrMalformedResp
+		return res, report, ErrMalformedResp
 	}
 	if len(layer3) == 0 {
-		return res, ErrMalformedResp
+		return res, report, ErrMalformedResp
 	}
 	layer4 := layer3[0]
 	res, ok = layer4.(map[string]any)
 	if !ok {
-		return res, ErrMalformedResp
+		return res, report, ErrMalformedResp
 	}
-	return res, err
+
+	for _, q := range requested {
+		m, ok := res[string(q)]
+		if !ok {
+			report.Missing = append(report.Missing, q)
+		} else if emptyModule(m) {
+			report.Empty = append(report.Empty, q)
+		}
+	}
+	return res, report, err
+}
+
+// emptyModule reports whether a module contains no data other than its maxAge.
+func emptyModule(m any) bool {
+	switch v := m.(type) {
+	case nil:
+		return true
+	case []any:
+		return len(v) == 0
+	case map[string]any:
+		for k := range v {
+			if k != "maxAge" {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// QuoteSummaryResult contains the response for a single symbol of a batch QuoteSummary request.
+type QuoteSummaryResult struct {
+	Symbol  string
+	Summary QuoteSummary
+	Report  ModuleReport
+	Err     error
+}
+
+// GetQuoteSummaries retrieves the same quoteParams for each of the given symbols.
+// Requests are spaced out by the WaitPeriod and no more than MaxConcurrency requests are in flight at once.
+// Results are returned in the same order as symbols; errors are included in each QuoteSummaryResult and are not returned separately.
+func (c *Client) GetQuoteSummaries(symbols []string, quoteParams []QuoteParam) []QuoteSummaryResult {
+	res := make([]QuoteSummaryResult, len(symbols))
+	limit := c.MaxConcurrency
+	if limit < 1 {
+		limit = 1
+	}
+	sem := make(chan struct{}, limit)
+	done := make(chan int, len(symbols))
+	for i := 0; i < len(symbols); i++ {
+		if i > 0 {
+			time.Sleep(c.WaitPeriod)
+		}
+		sem <- struct{}{}
+		go func(j int) {
+			defer func() { <-sem }()
+			summary, report, err := c.GetQuoteSummaryReport(symbols[j], quoteParams)
+			res[j] = QuoteSummaryResult{Symbol: symbols[j], Summary: summary, Report: report, Err: err}
+			done <- j
+		}(i)
+	}
+	for i := 0; i < len(symbols); i++ {
+		j := <-done
+		if c.Verbose {
+			// responses are logged in the order they are received
+			log.Println(symbols[j], res[j].Err)
+		}
+	}
+	return res
 }
@@ -0,0 +1,284 @@
+package yfi
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// PredefinedScreen identifies one of Yahoo's saved screens.
+type PredefinedScreen string
+
+const (
+	DayGainers               PredefinedScreen = "day_gainers"
+	DayLosers                PredefinedScreen = "day_losers"
+	MostActives              PredefinedScreen = "most_actives"
+	MostShortedStocks        PredefinedScreen = "most_shorted_stocks"
+	UndervaluedGrowthStocks  PredefinedScreen = "undervalued_growth_stocks"
+	UndervaluedLargeCaps     PredefinedScreen = "undervalued_large_caps"
+	GrowthTechnologyStocks   PredefinedScreen = "growth_technology_stocks"
+	AggressiveSmallCaps      PredefinedScreen = "aggressive_small_caps"
+	SmallCapGainers          PredefinedScreen = "small_cap_gainers"
+	PortfolioAnchors         PredefinedScreen = "portfolio_anchors"
+	SolidLargeGrowthFunds    PredefinedScreen = "solid_large_growth_funds"
+	SolidMidcapGrowthFunds   PredefinedScreen = "solid_midcap_growth_funds"
+	ConservativeForeignFunds PredefinedScreen = "conservative_foreign_funds"
+	HighYieldBond            PredefinedScreen = "high_yield_bond"
+	TopMutualFunds           PredefinedScreen = "top_mutual_funds"
+)
+
+// ScreenerQuery is a condition of a custom screen, e.g. Gt("intradaymarketcap", 1e10).
+// Conditions are combined with And and Or.
+type ScreenerQuery struct {
+	Operator string `json:"operator"`
+	Operands []any  `json:"operands"`
+}
+
+// Eq matches rows where field equals value.
+func Eq(field string, value any) ScreenerQuery {
+	return ScreenerQuery{"eq", []any{field, value}}
+}
+
+// Gt matches rows where field is greater than value.
+func Gt(field string, value any) ScreenerQuery {
+	return ScreenerQuery{"gt", []any{field, value}}
+}
+
+// Gte matches rows where field is greater than or equal to value.
+func Gte(field string, value any) ScreenerQuery {
+	return ScreenerQuery{"gte", []any{field, value}}
+}
+
+// Lt matches rows where field is less than value.
+func Lt(field string, value any) ScreenerQuery {
+	return ScreenerQuery{"lt", []any{field, value}}
+}
+
+// Lte matches rows where field is less than or equal to value.
+func Lte(field string, value any) ScreenerQuery {
+	return ScreenerQuery{"lte", []any{field, value}}
+}
+
+// Btwn matches rows where field is between lo and hi.
+func Btwn(field string, lo, hi any) ScreenerQuery {
+	return ScreenerQuery{"btwn", []any{field, lo, hi}}
+}
+
+// IsIn matches rows where field equals any of values.
+func IsIn(field string, values ...any) ScreenerQuery {
+	qs := make([]ScreenerQuery, len(values))
+	for i, v := range values {
+		qs[i] = Eq(field, v)
+	}
+	return Or(qs...)
+}
+
+// And matches rows that satisfy every one of qs.
+func And(qs ...ScreenerQuery) ScreenerQuery {
+	return ScreenerQuery{"and", queryOperands(qs)}
+}
+
+// Or matches rows that satisfy any of qs.
+func Or(qs ...ScreenerQuery) ScreenerQuery {
+	return ScreenerQuery{"or", queryOperands(qs)}
+}
+
+func queryOperands(qs []ScreenerQuery) []any {
+	res := make([]any, len(qs))
+	for i := 0; i < len(qs); i++ {
+		res[i] = qs[i]
+	}
+	return res
+}
+
+// Screener describes either a predefined or a custom screen.
+// Screeners are built with NewScreener or NewPredefinedScreener, and configured by chaining methods, e.g.
+//
+//	s := NewScreener(And(Eq("region", "us"), Gt("intradaymarketcap", 1e10))).SortBy("percentchange", false).PageSize(100)
+type Screener struct {
+	predefined PredefinedScreen
+	query      ScreenerQuery
+	quoteType  string
+	sortField  string
+	sortAsc    bool
+	pageSize   int
+	limit      int
+}
+
+// NewScreener returns a custom screen for equities matching query, sorted by descending market cap.
+func NewScreener(query ScreenerQuery) *Screener {
+	return &Screener{
+		query:     query,
+		quoteType: "EQUITY",
+		sortField: "intradaymarketcap",
+		pageSize:  25,
+	}
+}
+
+// NewPredefinedScreener returns one of Yahoo's saved screens.
+func NewPredefinedScreener(id PredefinedScreen) *Screener {
+	return &Screener{predefined: id, pageSize: 25}
+}
+
+// QuoteType restricts a custom screen to a quote type such as "EQUITY", "ETF" or "MUTUALFUND".
+func (s *Screener) QuoteType(quoteType string) *Screener {
+	s.quoteType = quoteType
+	return s
+}
+
+// SortBy sorts the results of a custom screen by field.
+func (s *Screener) SortBy(field string, ascending bool) *Screener {
+	s.sortField = field
+	s.sortAsc = ascending
+	return s
+}
+
+// PageSize sets the number of rows requested at a time. Yahoo allows at most 250.
+func (s *Screener) PageSize(n int) *Screener {
+	if n > 250 {
+		n = 250
+	}
+	if n > 0 {
+		s.pageSize = n
+	}
+	return s
+}
+
+// Limit sets the maximum total number of rows returned by a ScreenerIterator; 0 means no limit.
+func (s *Screener) Limit(n int) *Screener {
+	s.limit = n
+	return s
+}
+
+// ScreenerPage is a single page of screen results.
+type ScreenerPage struct {
+	Id          string  `json:"id"`
+	Title       string  `json:"title"`
+	Description string  `json:"description"`
+	Start       int     `json:"start"`
+	Count       int     `json:"count"`
+	Total       int     `json:"total"`
+	Quotes      []Quote `json:"quotes"`
+}
+
+type outerScreenerResp struct {
+	Finance screenerResp `json:"finance"`
+}
+
+type screenerResp struct {
+	Result []ScreenerPage `json:"result"`
+	Error  any            `json:"error"`
+}
+
+type screenerBody struct {
+	Size      int           `json:"size"`
+	Offset    int           `json:"offset"`
+	SortField string        `json:"sortField"`
+	SortType  string        `json:"sortType"`
+	QuoteType string        `json:"quoteType"`
+	Query     ScreenerQuery `json:"query"`
+}
+
+// Screen retrieves a single page of results starting at offset.
+func (c *Client) Screen(s *Screener, offset int) (ScreenerPage, error) {
+	var v outerScreenerResp
+	var err error
+	if s.predefined != "" {
+		url := V1 + "screener/predefined/saved?scrIds=" + string(s.predefined) +
+			"&count=" + strconv.Itoa(s.pageSize) +
+			"&start=" + strconv.Itoa(offset)
+		err = c.getJSON(url, &v)
+	} else {
+		sortType := "DESC"
+		if s.sortAsc {
+			sortType = "ASC"
+		}
+		body := screenerBody{
+			Size:      s.pageSize,
+			Offset:    offset,
+			SortField: s.sortField,
+			SortType:  sortType,
+			QuoteType: s.quoteType,
+			Query:     s.query,
+		}
+		err = c.doJSON(http.MethodPost, V1+"screener", body, &v)
+	}
+	if err != nil {
+		return ScreenerPage{}, err
+	}
+	if len(v.Finance.Result) == 0 {
+		return ScreenerPage{}, ErrMalformedResp
+	}
+	return v.Finance.Result[0], nil
+}
+
+// ScreenerIterator iterates over every row of a screen, requesting pages as needed.
+//
+//	it := c.ScreenerIterator(s)
+//	for it.Next() {
+//		q := it.Quote()
+//	}
+//	if it.Err() != nil { ... }
+type ScreenerIterator struct {
+	c       *Client
+	s       *Screener
+	offset  int
+	total   int
+	seen    int
+	fetched bool
+	buf     []Quote
+	cur     Quote
+	err     error
+}
+
+// ScreenerIterator returns an iterator over the rows of s.
+// Each page after the first is preceded by a WaitPeriod to reduce the risk of rate limiting.
+func (c *Client) ScreenerIterator(s *Screener) *ScreenerIterator {
+	return &ScreenerIterator{c: c, s: s}
+}
+
+// Next advances the iterator to the next row. It returns false when there are no more rows or an error occurs.
+func (it *ScreenerIterator) Next() bool {
+	if it.err != nil || (it.s.limit > 0 && it.seen >= it.s.limit) {
+		return false
+	}
+	if len(it.buf) == 0 {
+		if it.fetched && it.offset >= it.total {
+			return false
+		}
+		if it.fetched {
+			time.Sleep(it.c.WaitPeriod)
+		}
+		page, err := it.c.Screen(it.s, it.offset)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		it.fetched = true
+		it.total = page.Total
+		it.offset += len(page.Quotes)
+		it.buf = page.Quotes
+		if len(it.buf) == 0 {
+			return false
+		}
+	}
+	it.cur = it.buf[0]
+	it.buf = it.buf[1:]
+	it.seen++
+	return true
+}
+
+// Quote returns the current row.
+func (it *ScreenerIterator) Quote() Quote {
+	return it.cur
+}
+
+// Total returns the total number of rows matching the screen, as reported by the first page.
+func (it *ScreenerIterator) Total() int {
+	return it.total
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *ScreenerIterator) Err() error {
+	return it.err
+}
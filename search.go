@@ -0,0 +1,190 @@
+package yfi
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// SearchQuote is a listing matched by Search.
+type SearchQuote struct {
+	Symbol         string  `json:"symbol"`
+	ShortName      string  `json:"shortname"`
+	LongName       string  `json:"longname"`
+	Exchange       string  `json:"exchange"`
+	ExchDisp       string  `json:"exchDisp"`
+	QuoteType      string  `json:"quoteType"`
+	TypeDisp       string  `json:"typeDisp"`
+	Index          string  `json:"index"`
+	Score          float64 `json:"score"`
+	Sector         string  `json:"sector"`
+	Industry       string  `json:"industry"`
+	IsYahooFinance bool    `json:"isYahooFinance"`
+}
+
+// NewsItem is a news article returned by Search.
+type NewsItem struct {
+	Uuid                string   `json:"uuid"`
+	Title               string   `json:"title"`
+	Publisher           string   `json:"publisher"`
+	Link                string   `json:"link"`
+	ProviderPublishTime int64    `json:"providerPublishTime"`
+	Type                string   `json:"type"`
+	RelatedTickers      []string `json:"relatedTickers"`
+}
+
+// SearchResult contains the listings and news articles matching a search query.
+type SearchResult struct {
+	Count  int           `json:"count"`
+	Quotes []SearchQuote `json:"quotes"`
+	News   []NewsItem    `json:"news"`
+}
+
+// Search queries Yahoo Finance for listings and news matching query, which may be a
+// company name, a partial symbol or an ISIN. At most quotesCount listings and newsCount
+// articles are returned; values less than 1 leave the choice to Yahoo.
+func (c *Client) Search(query string, quotesCount, newsCount int) (SearchResult, error) {
+	var res SearchResult
+	u := V1 + "search?q=" + url.QueryEscape(query)
+	if quotesCount > 0 {
+		u += "&quotesCount=" + strconv.Itoa(quotesCount)
+	}
+	if newsCount > 0 {
+		u += "&newsCount=" + strconv.Itoa(newsCount)
+	}
+	err := c.getJSON(u, &res)
+	return res, err
+}
+
+// ResolveSymbol returns the listing that most likely corresponds to query.
+// Listings that match query exactly are preferred; otherwise the listing with
+// the highest score is chosen. ErrNoMatch is returned if nothing matches.
+func (c *Client) ResolveSymbol(query string) (SearchQuote, error) {
+	var res SearchQuote
+	sr, err := c.Search(query, 10, 0)
+	if err != nil {
+		return res, err
+	}
+	found := false
+	for _, q := range sr.Quotes {
+		if q.Symbol == "" {
+			continue
+		}
+		if strings.EqualFold(q.Symbol, query) {
+			return q, nil
+		}
+		if !found || q.Score > res.Score {
+			res = q
+			found = true
+		}
+	}
+	if !found {
+		return res, ErrNoMatch
+	}
+	return res, nil
+}
+
+// ResolveISIN returns the listing that most likely corresponds to isin.
+// ErrIdentifier is returned if isin does not have a valid check digit.
+func (c *Client) ResolveISIN(isin string) (SearchQuote, error) {
+	isin = strings.ToUpper(strings.TrimSpace(isin))
+	if !IsISIN(isin) {
+		return SearchQuote{}, ErrIdentifier
+	}
+	return c.ResolveSymbol(isin)
+}
+
+// ResolveCUSIP returns the listing that most likely corresponds to cusip.
+// Yahoo does not index CUSIPs directly, so cusip is converted to the equivalent
+// US ISIN before searching. ErrIdentifier is returned if cusip does not have a valid check digit.
+func (c *Client) ResolveCUSIP(cusip string) (SearchQuote, error) {
+	cusip = strings.ToUpper(strings.TrimSpace(cusip))
+	if !IsCUSIP(cusip) {
+		return SearchQuote{}, ErrIdentifier
+	}
+	isin := "US" + cusip
+	return c.ResolveSymbol(isin + strconv.Itoa(isinCheckDigit(isin)))
+}
+
+// IsISIN reports whether s is a well-formed ISIN with a valid check digit.
+func IsISIN(s string) bool {
+	if len(s) != 12 {
+		return false
+	}
+	for i := 0; i < 12; i++ {
+		ch := s[i]
+		switch {
+		case i < 2 && (ch < 'A' || ch > 'Z'):
+			return false
+		case i == 11 && (ch < '0' || ch > '9'):
+			return false
+		case !isAlnum(ch):
+			return false
+		}
+	}
+	return isinCheckDigit(s[:11]) == int(s[11]-'0')
+}
+
+// isinCheckDigit computes the check digit of the first 11 characters of an ISIN
+// by expanding letters to numbers and applying the Luhn algorithm.
+func isinCheckDigit(s string) int {
+	digits := ""
+	for i := 0; i < len(s); i++ {
+		digits += strconv.Itoa(alnumValue(s[i]))
+	}
+	sum := 0
+	double := true
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return (10 - sum%10) % 10
+}
+
+// IsCUSIP reports whether s is a well-formed CUSIP with a valid check digit.
+func IsCUSIP(s string) bool {
+	if len(s) != 9 || s[8] < '0' || s[8] > '9' {
+		return false
+	}
+	sum := 0
+	for i := 0; i < 8; i++ {
+		ch := s[i]
+		var v int
+		switch {
+		case isAlnum(ch):
+			v = alnumValue(ch)
+		case ch == '*':
+			v = 36
+		case ch == '@':
+			v = 37
+		case ch == '#':
+			v = 38
+		default:
+			return false
+		}
+		if i%2 == 1 {
+			v *= 2
+		}
+		sum += v/10 + v%10
+	}
+	return (10-sum%10)%10 == int(s[8]-'0')
+}
+
+func isAlnum(ch byte) bool {
+	return (ch >= '0' && ch <= '9') || (ch >= 'A' && ch <= 'Z')
+}
+
+// alnumValue maps 0-9 to 0-9 and A-Z to 10-35.
+func alnumValue(ch byte) int {
+	if ch >= '0' && ch <= '9' {
+		return int(ch - '0')
+	}
+	return int(ch-'A') + 10
+}
@@ -0,0 +1,75 @@
+package yfi
+
+import "encoding/binary"
+
+// snappyDecode decodes a block in the Snappy format, which Parquet uses for compressed pages
+// (https://github.com/google/snappy/blob/main/format_description.txt).
+func snappyDecode(src []byte) ([]byte, error) {
+	n, k := binary.Uvarint(src)
+	// a copy element expands at most 3 bytes to 64, so larger lengths cannot be valid
+	if k <= 0 || n > 32*uint64(len(src)) {
+		return nil, ErrParquet
+	}
+	src = src[k:]
+	dst := make([]byte, 0, n)
+	for len(src) > 0 {
+		tag := src[0]
+		var length, offset int
+		switch tag & 3 {
+		case 0: // literal
+			length = int(tag >> 2)
+			src = src[1:]
+			if length >= 60 {
+				extra := length - 59
+				if len(src) < extra {
+					return nil, ErrParquet
+				}
+				length = 0
+				for i := extra - 1; i >= 0; i-- {
+					length = length<<8 | int(src[i])
+				}
+				src = src[extra:]
+			}
+			length++
+			if length <= 0 || length > len(src) || uint64(len(dst)+length) > n {
+				return nil, ErrParquet
+			}
+			dst = append(dst, src[:length]...)
+			src = src[length:]
+			continue
+		case 1: // copy with a 1-byte offset
+			if len(src) < 2 {
+				return nil, ErrParquet
+			}
+			length = 4 + int(tag>>2)&7
+			offset = int(tag&0xe0)<<3 | int(src[1])
+			src = src[2:]
+		case 2: // copy with a 2-byte offset
+			if len(src) < 3 {
+				return nil, ErrParquet
+			}
+			length = 1 + int(tag>>2)
+			offset = int(binary.LittleEndian.Uint16(src[1:]))
+			src = src[3:]
+		case 3: // copy with a 4-byte offset
+			if len(src) < 5 {
+				return nil, ErrParquet
+			}
+			length = 1 + int(tag>>2)
+			offset = int(binary.LittleEndian.Uint32(src[1:]))
+			src = src[5:]
+		}
+		if offset <= 0 || offset > len(dst) || uint64(len(dst)+length) > n {
+			return nil, ErrParquet
+		}
+		// the source and destination may overlap, which repeats the last offset bytes
+		start := len(dst) - offset
+		for i := 0; i < length; i++ {
+			dst = append(dst, dst[start+i])
+		}
+	}
+	if uint64(len(dst)) != n {
+		return nil, ErrParquet
+	}
+	return dst, nil
+}
@@ -0,0 +1,312 @@
+package yfi
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+type sqlType string
+
+const (
+	sqlText  sqlType = "TEXT"
+	sqlInt   sqlType = "BIGINT"
+	sqlFloat sqlType = "DOUBLE PRECISION"
+	sqlBool  sqlType = "BOOLEAN"
+)
+
+type sqlColumn struct {
+	name string
+	typ  sqlType
+}
+
+type sqlTable struct {
+	name string
+	cols []sqlColumn
+	key  []string
+}
+
+// snakeCase converts a camelCase JSON key to snake_case, e.g. averageDailyVolume10Day to average_daily_volume_10_day.
+func snakeCase(s string) string {
+	r := []rune(s)
+	var sb strings.Builder
+	for i, c := range r {
+		if i > 0 {
+			prev := r[i-1]
+			switch {
+			case unicode.IsUpper(c) && (unicode.IsLower(prev) || unicode.IsDigit(prev)),
+				unicode.IsUpper(c) && unicode.IsUpper(prev) && i+1 < len(r) && unicode.IsLower(r[i+1]),
+				unicode.IsDigit(c) && unicode.IsLetter(prev):
+				sb.WriteByte('_')
+			}
+		}
+		sb.WriteRune(unicode.ToLower(c))
+	}
+	return sb.String()
+}
+
+var (
+	barsTable = sqlTable{
+		name: "bars",
+		cols: []sqlColumn{
+			{"symbol", sqlText}, {"interval", sqlText}, {"time", sqlInt},
+			{"open", sqlFloat}, {"high", sqlFloat}, {"low", sqlFloat}, {"close", sqlFloat}, {"adj_close", sqlFloat},
+			{"volume", sqlInt},
+		},
+		key: []string{"symbol", "interval", "time"},
+	}
+	fundamentalsTable = sqlTable{
+		name: "fundamentals",
+		cols: []sqlColumn{
+			{"symbol", sqlText}, {"freq", sqlText}, {"item", sqlText}, {"as_of_date", sqlText},
+			{"period_type", sqlText}, {"currency", sqlText}, {"value", sqlFloat},
+		},
+		key: []string{"symbol", "freq", "item", "as_of_date"},
+	}
+	esgTable = sqlTable{
+		name: "esg_scores",
+		cols: []sqlColumn{
+			{"symbol", sqlText}, {"rating_year", sqlInt}, {"rating_month", sqlInt},
+			{"total_esg", sqlFloat}, {"environment_score", sqlFloat}, {"social_score", sqlFloat}, {"governance_score", sqlFloat},
+			{"highest_controversy", sqlFloat}, {"percentile", sqlFloat}, {"peer_group", sqlText}, {"esg_performance", sqlText},
+		},
+		key: []string{"symbol", "rating_year", "rating_month"},
+	}
+	calendarTable = sqlTable{
+		name: "calendar_events",
+		cols: []sqlColumn{
+			{"symbol", sqlText}, {"type", sqlText}, {"date", sqlText}, {"end_date", sqlText}, {"name", sqlText}, {"time_type", sqlText},
+			{"eps_estimate", sqlFloat}, {"eps_low", sqlFloat}, {"eps_high", sqlFloat}, {"eps_actual", sqlFloat}, {"surprise_percent", sqlFloat},
+			{"revenue_estimate", sqlFloat}, {"revenue_low", sqlFloat}, {"revenue_high", sqlFloat},
+			{"offer_price", sqlFloat}, {"exchange", sqlText},
+		},
+		key: []string{"symbol", "type", "date"},
+	}
+)
+
+// quotesTable has a column for every field of Quote, named after its JSON key in snake_case.
+func quotesTable() (sqlTable, []int) {
+	cols, fields := quoteColumns()
+	t := sqlTable{name: "quotes", key: []string{"symbol", "regular_market_time"}}
+	for _, c := range cols {
+		typ := sqlText
+		switch c.typ {
+		case pqDouble:
+			typ = sqlFloat
+		case pqInt64:
+			typ = sqlInt
+		case pqBoolean:
+			typ = sqlBool
+		}
+		t.cols = append(t.cols, sqlColumn{snakeCase(c.name), typ})
+	}
+	return t, fields
+}
+
+// SQLiteSink writes Ticker bars, Quote snapshots, fundamentals, ESG scores and calendar events to the tables of
+// an SQLite 3.24 or later database, so that they can be analyzed with SQL queries. It goes through database/sql,
+// but relies on SQLite's upsert syntax and type affinity, so other databases are not supported. Rows are upserted
+// on their key, so writing the same data twice leaves a single, up to date row. Times are stored as Unix seconds
+// and dates as 2006-01-02 strings; Values that are not Valid are stored as NULL.
+//
+// The tables, whose names start with Prefix, are:
+//
+//	bars (symbol, interval, time, open, high, low, close, adj_close, volume), keyed by symbol, interval and time
+//	quotes (one column per Quote field, e.g. regular_market_price), keyed by symbol and regular_market_time
+//	fundamentals (symbol, freq, item, as_of_date, period_type, currency, value), keyed by symbol, freq, item and as_of_date
+//	esg_scores (symbol, rating_year, rating_month, total_esg, ...), keyed by symbol, rating_year and rating_month
+//	calendar_events (symbol, type, date, end_date, name, ...), keyed by symbol, type and date
+//
+// Call Migrate before writing.
+type SQLiteSink struct {
+	DB     *sql.DB
+	Prefix string
+}
+
+// NewSQLiteSink returns an SQLiteSink that writes to the SQLite database db, using tables prefixed with "yfi_".
+func NewSQLiteSink(db *sql.DB) *SQLiteSink {
+	return &SQLiteSink{DB: db, Prefix: "yfi_"}
+}
+
+func quoteIdent(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+func (s *SQLiteSink) tables() []sqlTable {
+	quotes, _ := quotesTable()
+	return []sqlTable{barsTable, quotes, fundamentalsTable, esgTable, calendarTable}
+}
+
+// Migrate creates the tables that do not exist yet and adds the columns that are missing from existing ones,
+// e.g. after Quote has gained fields. Columns are never dropped or altered.
+func (s *SQLiteSink) Migrate(ctx context.Context) error {
+	for _, t := range s.tables() {
+		name := quoteIdent(s.Prefix + t.name)
+		defs := make([]string, 0, len(t.cols)+1)
+		for _, c := range t.cols {
+			def := quoteIdent(c.name) + " " + string(c.typ)
+			for _, k := range t.key {
+				if k == c.name {
+					def += " NOT NULL"
+				}
+			}
+			defs = append(defs, def)
+		}
+		keys := make([]string, len(t.key))
+		for i, k := range t.key {
+			keys[i] = quoteIdent(k)
+		}
+		defs = append(defs, "PRIMARY KEY ("+strings.Join(keys, ", ")+")")
+		if _, err := s.DB.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS "+name+" ("+strings.Join(defs, ", ")+")"); err != nil {
+			return err
+		}
+
+		rows, err := s.DB.QueryContext(ctx, "SELECT * FROM "+name+" WHERE 1 = 0")
+		if err != nil {
+			return err
+		}
+		existing, err := rows.Columns()
+		rows.Close()
+		if err != nil {
+			return err
+		}
+		have := make(map[string]bool, len(existing))
+		for _, c := range existing {
+			have[strings.ToLower(c)] = true
+		}
+		for _, c := range t.cols {
+			if have[c.name] {
+				continue
+			}
+			if _, err = s.DB.ExecContext(ctx, "ALTER TABLE "+name+" ADD COLUMN "+quoteIdent(c.name)+" "+string(c.typ)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// upsert returns the statement that inserts a row into t or updates the row with the same key.
+func (s *SQLiteSink) upsert(t sqlTable) string {
+	cols := make([]string, len(t.cols))
+	params := make([]string, len(t.cols))
+	var updates []string
+	for i, c := range t.cols {
+		cols[i] = quoteIdent(c.name)
+		params[i] = "?"
+		isKey := false
+		for _, k := range t.key {
+			isKey = isKey || k == c.name
+		}
+		if !isKey {
+			updates = append(updates, cols[i]+" = excluded."+cols[i])
+		}
+	}
+	keys := make([]string, len(t.key))
+	for i, k := range t.key {
+		keys[i] = quoteIdent(k)
+	}
+	return "INSERT INTO " + quoteIdent(s.Prefix+t.name) + " (" + strings.Join(cols, ", ") + ") VALUES (" + strings.Join(params, ", ") +
+		") ON CONFLICT (" + strings.Join(keys, ", ") + ") DO UPDATE SET " + strings.Join(updates, ", ")
+}
+
+// write upserts rows into t within a single transaction.
+func (s *SQLiteSink) write(ctx context.Context, t sqlTable, rows [][]any) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	stmt, err := tx.PrepareContext(ctx, s.upsert(t))
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	for _, row := range rows {
+		if _, err = stmt.ExecContext(ctx, row...); err != nil {
+			stmt.Close()
+			tx.Rollback()
+			return err
+		}
+	}
+	stmt.Close()
+	return tx.Commit()
+}
+
+func sqlValue(v Value) sql.NullFloat64 {
+	return sql.NullFloat64{Float64: v.Raw, Valid: v.Valid}
+}
+
+// WriteTickers upserts the bars of tickers.
+func (s *SQLiteSink) WriteTickers(ctx context.Context, tickers ...Ticker) error {
+	var rows [][]any
+	for i := range tickers {
+		t := &tickers[i]
+		if err := t.validate(); err != nil {
+			return err
+		}
+		for _, b := range t.Bars() {
+			rows = append(rows, []any{t.Symbol, string(t.Interval), b.Time, b.Open, b.High, b.Low, b.Close, b.AdjClose, int64(b.Volume)})
+		}
+	}
+	return s.write(ctx, barsTable, rows)
+}
+
+// WriteQuotes upserts quotes as snapshots keyed by their RegularMarketTime.
+func (s *SQLiteSink) WriteQuotes(ctx context.Context, quotes ...Quote) error {
+	t, fields := quotesTable()
+	rows := make([][]any, len(quotes))
+	for i := range quotes {
+		v := reflect.ValueOf(quotes[i])
+		row := make([]any, len(fields))
+		for j, f := range fields {
+			switch t.cols[j].typ {
+			case sqlText:
+				row[j] = v.Field(f).String()
+			default:
+				row[j] = v.Field(f).Interface()
+			}
+		}
+		rows[i] = row
+	}
+	return s.write(ctx, t, rows)
+}
+
+// WriteFundamentals upserts every point of the series of f.
+func (s *SQLiteSink) WriteFundamentals(ctx context.Context, f Fundamentals) error {
+	var rows [][]any
+	for _, series := range f.Series {
+		for _, p := range series.Points {
+			rows = append(rows, []any{f.Symbol, string(series.Freq), string(series.Item), p.AsOfDate.Format("2006-01-02"),
+				p.PeriodType, p.CurrencyCode, sqlValue(p.ReportedValue)})
+		}
+	}
+	return s.write(ctx, fundamentalsTable, rows)
+}
+
+// WriteEsgScores upserts the ESG scores of symbol for the rating period of esg.
+func (s *SQLiteSink) WriteEsgScores(ctx context.Context, symbol string, esg EsgScoresModule) error {
+	return s.write(ctx, esgTable, [][]any{{symbol, int64(esg.RatingYear), int64(esg.RatingMonth),
+		sqlValue(esg.TotalEsg), sqlValue(esg.EnvironmentScore), sqlValue(esg.SocialScore), sqlValue(esg.GovernanceScore),
+		sqlValue(esg.HighestControversy), sqlValue(esg.Percentile), esg.PeerGroup, esg.EsgPerformance}})
+}
+
+// WriteCalendar upserts the events of cal.
+func (s *SQLiteSink) WriteCalendar(ctx context.Context, cal Calendar) error {
+	rows := make([][]any, len(cal.Events))
+	for i, ev := range cal.Events {
+		var end sql.NullString
+		if !ev.EndDate.IsZero() {
+			end = sql.NullString{String: ev.EndDate.UTC().Format("2006-01-02"), Valid: true}
+		}
+		rows[i] = []any{ev.Symbol, string(ev.Type), ev.Date.UTC().Format("2006-01-02"), end, ev.Name, ev.TimeType,
+			sqlValue(ev.EarningsAverage), sqlValue(ev.EarningsLow), sqlValue(ev.EarningsHigh), sqlValue(ev.EarningsActual),
+			sqlValue(ev.SurprisePercent), sqlValue(ev.RevenueAverage), sqlValue(ev.RevenueLow), sqlValue(ev.RevenueHigh),
+			sqlValue(ev.OfferPrice), ev.Exchange}
+	}
+	return s.write(ctx, calendarTable, rows)
+}
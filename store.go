@@ -0,0 +1,411 @@
+package yfi
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"math"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Bar is a single row of a Ticker's history. Time is in Unix seconds, like Ticker.HistoricDates.
+type Bar struct {
+	Time     int64   `json:"t"`
+	Open     float64 `json:"o"`
+	High     float64 `json:"h"`
+	Low      float64 `json:"l"`
+	Close    float64 `json:"c"`
+	AdjClose float64 `json:"a"`
+	Volume   int     `json:"v"`
+}
+
+// Bars returns the history of t as a slice of Bars.
+func (t *Ticker) Bars() []Bar {
+	res := make([]Bar, len(t.HistoricDates))
+	for i := range res {
+		res[i] = Bar{
+			Time:     t.HistoricDates[i],
+			Open:     t.HistoricOpen[i],
+			High:     t.HistoricHigh[i],
+			Low:      t.HistoricLow[i],
+			Close:    t.HistoricClose[i],
+			AdjClose: t.HistoricAdjClose[i],
+			Volume:   t.HistoricVolume[i],
+		}
+	}
+	return res
+}
+
+// TickerFromBars returns a Ticker containing bars.
+func TickerFromBars(symbol string, interval TimeSpan, bars []Bar) Ticker {
+	t := Ticker{
+		Symbol:           symbol,
+		Interval:         interval,
+		HistoricDates:    make([]int64, len(bars)),
+		HistoricOpen:     make([]float64, len(bars)),
+		HistoricHigh:     make([]float64, len(bars)),
+		HistoricLow:      make([]float64, len(bars)),
+		HistoricClose:    make([]float64, len(bars)),
+		HistoricAdjClose: make([]float64, len(bars)),
+		HistoricVolume:   make([]int, len(bars)),
+	}
+	for i, b := range bars {
+		t.HistoricDates[i] = b.Time
+		t.HistoricOpen[i] = b.Open
+		t.HistoricHigh[i] = b.High
+		t.HistoricLow[i] = b.Low
+		t.HistoricClose[i] = b.Close
+		t.HistoricAdjClose[i] = b.AdjClose
+		t.HistoricVolume[i] = b.Volume
+	}
+	return t
+}
+
+// mergeBars merges update into bars, both sorted by Time. Bars in update replace bars with the same Time.
+// It returns the merged bars and the number of bars that were not already present.
+func mergeBars(bars, update []Bar) ([]Bar, int) {
+	res := make([]Bar, 0, len(bars)+len(update))
+	added := 0
+	i, j := 0, 0
+	for i < len(bars) || j < len(update) {
+		switch {
+		case j == len(update) || (i < len(bars) && bars[i].Time < update[j].Time):
+			res = append(res, bars[i])
+			i++
+		case i == len(bars) || update[j].Time < bars[i].Time:
+			res = append(res, update[j])
+			added++
+			j++
+		default:
+			res = append(res, update[j])
+			i++
+			j++
+		}
+	}
+	return res, added
+}
+
+// storedHistory is the content of a BarStore file. From and To are the bounds, in Unix seconds,
+// of the time range that has been requested from Yahoo; they may extend beyond the first and
+// last bars, e.g. over weekends.
+type storedHistory struct {
+	Symbol      string            `json:"symbol"`
+	Interval    TimeSpan          `json:"interval"`
+	From        int64             `json:"from"`
+	To          int64             `json:"to"`
+	Bars        []Bar             `json:"bars"`
+	Adjustments []AdjustmentEvent `json:"adjustments,omitempty"`
+}
+
+// AdjustmentEvent records a change of adjustment basis detected by BarStore.Update. Yahoo recomputes
+// the adjusted close of the whole history whenever a dividend is paid or a split occurs, so bars
+// fetched before and after such an event are not comparable. Factor is the ratio of the new adjusted
+// close to the stored one for the bar at BarTime, which both the stored and newly fetched histories contain.
+type AdjustmentEvent struct {
+	Symbol   string    `json:"symbol"`
+	Interval TimeSpan  `json:"interval"`
+	Detected time.Time `json:"detected"`
+	BarTime  int64     `json:"barTime"`
+	Factor   float64   `json:"factor"`
+	// Refetched is true if the stored history was downloaded again rather than rescaled.
+	Refetched bool `json:"refetched"`
+}
+
+// adjTolerance is the relative change of the ratio of adjusted close to close that is attributed
+// to rounding rather than to a change of adjustment basis.
+const adjTolerance = 1e-5
+
+// adjFactor compares the overlapping bars of stored and fetched, both sorted by Time, and returns
+// the factor by which the adjusted closes of stored must be multiplied to match the basis of fetched.
+// Ratios of adjusted close to close are compared so that revisions of an incomplete last bar are ignored.
+func adjFactor(stored, fetched []Bar) (factor float64, barTime int64, revised bool) {
+	for _, f := range fetched {
+		i := sort.Search(len(stored), func(i int) bool { return stored[i].Time >= f.Time })
+		if i == len(stored) || stored[i].Time != f.Time {
+			continue
+		}
+		b := stored[i]
+		if b.Close == 0 || b.AdjClose == 0 || f.Close == 0 {
+			continue
+		}
+		factor = (f.AdjClose / f.Close) / (b.AdjClose / b.Close)
+		return factor, f.Time, math.Abs(factor-1) > adjTolerance
+	}
+	return 1, 0, false
+}
+
+// BarStore is a local store of historical bars, kept as one file per symbol and interval in Dir.
+// Update requests only the parts of a time range that have not been fetched before, so that
+// histories can be refreshed cheaply, and Load serves range queries without network access.
+// A BarStore is safe for concurrent use, but a Dir should not be shared by several BarStores.
+//
+// Newly fetched bars always overlap at least one stored bar, which is used to detect changes of the
+// adjustment basis. When one is detected, the stored adjusted closes are rescaled to the new basis,
+// or the stored range is downloaded again if RefetchOnRevision is set, and an AdjustmentEvent is recorded.
+type BarStore struct {
+	Dir               string
+	Client            *Client
+	RefetchOnRevision bool
+	// OnAdjustment, if not nil, is called with every AdjustmentEvent.
+	OnAdjustment func(AdjustmentEvent)
+
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+// OpenBarStore returns a BarStore that keeps its files in dir, creating dir if necessary.
+// c is used by Update and may be nil if the store is only read.
+func OpenBarStore(dir string, c *Client) (*BarStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &BarStore{Dir: dir, Client: c, locks: make(map[string]*sync.Mutex)}, nil
+}
+
+func (s *BarStore) path(symbol string, interval TimeSpan) string {
+	return filepath.Join(s.Dir, url.PathEscape(symbol)+"_"+string(interval)+".json")
+}
+
+// lock locks the file of symbol and interval and returns the function that unlocks it.
+func (s *BarStore) lock(symbol string, interval TimeSpan) func() {
+	key := symbol + "_" + string(interval)
+	s.mu.Lock()
+	l, ok := s.locks[key]
+	if !ok {
+		l = new(sync.Mutex)
+		s.locks[key] = l
+	}
+	s.mu.Unlock()
+	l.Lock()
+	return l.Unlock
+}
+
+func (s *BarStore) read(symbol string, interval TimeSpan) (storedHistory, error) {
+	h := storedHistory{Symbol: symbol, Interval: interval}
+	b, err := os.ReadFile(s.path(symbol, interval))
+	if errors.Is(err, os.ErrNotExist) {
+		return h, ErrNotStored
+	}
+	if err != nil {
+		return h, err
+	}
+	err = json.Unmarshal(b, &h)
+	return h, err
+}
+
+func (s *BarStore) write(h storedHistory) error {
+	b, err := json.Marshal(h)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(s.path(h.Symbol, h.Interval), b)
+}
+
+func (s *BarStore) fetch(symbol string, interval TimeSpan, start, end int64) ([]Bar, error) {
+	t, err := s.Client.GetTicker(symbol, interval, time.Unix(start, 0), time.Unix(end, 0))
+	if err != nil {
+		return nil, err
+	}
+	bars := t.Bars()
+	sort.Slice(bars, func(i, j int) bool { return bars[i].Time < bars[j].Time })
+	return bars, nil
+}
+
+// Update makes sure that the history of symbol between start and end is stored. Only the parts
+// of the range outside of what has already been fetched are requested; the most recent stored bar
+// is always requested again, since it may have been incomplete when it was fetched.
+// Update returns the number of bars that were added to the store.
+func (s *BarStore) Update(symbol string, interval TimeSpan, start, end time.Time) (int, error) {
+	if err := validateInterval(interval); err != nil {
+		return 0, err
+	}
+	if end.Before(start) {
+		return 0, ErrRange
+	}
+	unlock := s.lock(symbol, interval)
+	defer unlock()
+
+	h, err := s.read(symbol, interval)
+	if err != nil && err != ErrNotStored {
+		return 0, err
+	}
+	from, to := start.Unix(), end.Unix()
+	added := 0
+	if len(h.Bars) == 0 {
+		bars, err := s.fetch(symbol, interval, from, to)
+		if err != nil {
+			return 0, err
+		}
+		h.Bars, added = bars, len(bars)
+		h.From, h.To = from, to
+		return added, s.write(h)
+	}
+
+	if from < h.From {
+		// include the first stored bar
+		bars, err := s.fetch(symbol, interval, from, h.Bars[0].Time+1)
+		if err != nil {
+			return 0, err
+		}
+		if err = s.reconcile(&h, bars); err != nil {
+			return 0, err
+		}
+		var n int
+		h.Bars, n = mergeBars(h.Bars, bars)
+		added += n
+		h.From = from
+	}
+	if to > h.To {
+		bars, err := s.fetch(symbol, interval, h.Bars[len(h.Bars)-1].Time, to)
+		if err == nil {
+			err = s.reconcile(&h, bars)
+		}
+		if err != nil {
+			// keep the head if it was fetched
+			if added > 0 {
+				s.write(h)
+			}
+			return added, err
+		}
+		var n int
+		h.Bars, n = mergeBars(h.Bars, bars)
+		added += n
+		h.To = to
+	}
+	return added, s.write(h)
+}
+
+// reconcile brings the stored bars of h to the adjustment basis of fetched, recording an AdjustmentEvent
+// if the basis has changed.
+func (s *BarStore) reconcile(h *storedHistory, fetched []Bar) error {
+	factor, barTime, revised := adjFactor(h.Bars, fetched)
+	if !revised {
+		return nil
+	}
+	ev := AdjustmentEvent{
+		Symbol:    h.Symbol,
+		Interval:  h.Interval,
+		Detected:  time.Now().UTC(),
+		BarTime:   barTime,
+		Factor:    factor,
+		Refetched: s.RefetchOnRevision,
+	}
+	if s.RefetchOnRevision {
+		bars, err := s.fetch(h.Symbol, h.Interval, h.From, h.To)
+		if err != nil {
+			return err
+		}
+		// bars that are no longer returned keep their old basis, so they are dropped
+		h.Bars, _ = mergeBars(nil, bars)
+	} else {
+		for i := range h.Bars {
+			h.Bars[i].AdjClose *= factor
+		}
+	}
+	h.Adjustments = append(h.Adjustments, ev)
+	if s.OnAdjustment != nil {
+		s.OnAdjustment(ev)
+	}
+	if s.Client.Verbose {
+		log.Println(h.Symbol, "adjustment basis changed by a factor of", factor)
+	}
+	return nil
+}
+
+// StoreUpdate contains the result of updating a single symbol with UpdateAll.
+type StoreUpdate struct {
+	Symbol string
+	Added  int
+	Err    error
+}
+
+// UpdateAll calls Update for each of symbols. Requests are spaced out by the Client's WaitPeriod
+// and no more than MaxConcurrency updates run at once. Results are returned in the same order as symbols.
+func (s *BarStore) UpdateAll(symbols []string, interval TimeSpan, start, end time.Time) []StoreUpdate {
+	res := make([]StoreUpdate, len(symbols))
+	limit := s.Client.MaxConcurrency
+	if limit < 1 {
+		limit = 1
+	}
+	sem := make(chan struct{}, limit)
+	done := make(chan int, len(symbols))
+	for i := 0; i < len(symbols); i++ {
+		if i > 0 {
+			time.Sleep(s.Client.WaitPeriod)
+		}
+		sem <- struct{}{}
+		go func(j int) {
+			defer func() { <-sem }()
+			added, err := s.Update(symbols[j], interval, start, end)
+			res[j] = StoreUpdate{Symbol: symbols[j], Added: added, Err: err}
+			done <- j
+		}(i)
+	}
+	for i := 0; i < len(symbols); i++ {
+		j := <-done
+		if s.Client.Verbose {
+			log.Println(symbols[j], res[j].Added, res[j].Err)
+		}
+	}
+	return res
+}
+
+// Load returns the stored bars of symbol whose times fall between from and to, without making any requests.
+// ErrNotStored is returned if the history of symbol has never been fetched.
+func (s *BarStore) Load(symbol string, interval TimeSpan, from, to time.Time) (Ticker, error) {
+	unlock := s.lock(symbol, interval)
+	h, err := s.read(symbol, interval)
+	unlock()
+	if err != nil {
+		return Ticker{Symbol: symbol, Interval: interval, Err: err}, err
+	}
+	lo := sort.Search(len(h.Bars), func(i int) bool { return h.Bars[i].Time >= from.Unix() })
+	hi := sort.Search(len(h.Bars), func(i int) bool { return h.Bars[i].Time >= to.Unix() })
+	return TickerFromBars(symbol, interval, h.Bars[lo:hi]), nil
+}
+
+// Coverage returns the time range of symbol that has been fetched.
+func (s *BarStore) Coverage(symbol string, interval TimeSpan) (from, to time.Time, err error) {
+	unlock := s.lock(symbol, interval)
+	defer unlock()
+	h, err := s.read(symbol, interval)
+	if err != nil {
+		return from, to, err
+	}
+	return time.Unix(h.From, 0), time.Unix(h.To, 0), nil
+}
+
+// Adjustments returns the changes of adjustment basis that have been detected for symbol.
+func (s *BarStore) Adjustments(symbol string, interval TimeSpan) ([]AdjustmentEvent, error) {
+	unlock := s.lock(symbol, interval)
+	defer unlock()
+	h, err := s.read(symbol, interval)
+	return h.Adjustments, err
+}
+
+// writeFileAtomic replaces the file at path with b. The data is written to a temporary file
+// that is then renamed, so that a crash while writing does not leave a truncated file.
+func writeFileAtomic(path string, b []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Write(b); err == nil {
+		err = tmp.Sync()
+	}
+	if err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,415 @@
+package yfi
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+var errProtobuf = errors.New("malformed protobuf message")
+
+// MarketHours is the trading session a Tick was reported in.
+type MarketHours int32
+
+const (
+	PreMarket MarketHours = iota
+	RegularMarket
+	PostMarket
+	ExtendedHoursMarket
+)
+
+// MarketState returns the MarketState corresponding to h.
+func (h MarketHours) MarketState() MarketState {
+	switch h {
+	case PreMarket:
+		return MarketPre
+	case RegularMarket:
+		return MarketRegular
+	case PostMarket:
+		return MarketPost
+	default:
+		return MarketClosed
+	}
+}
+
+// Tick is a real-time price update decoded from the PricingData messages sent by the streamer.
+type Tick struct {
+	Symbol            string
+	Price             float64
+	Time              time.Time
+	Currency          string
+	Exchange          string
+	QuoteType         int32
+	MarketHours       MarketHours
+	ChangePercent     float64
+	DayVolume         int64
+	DayHigh           float64
+	DayLow            float64
+	Change            float64
+	ShortName         string
+	ExpireDate        int64
+	OpenPrice         float64
+	PreviousClose     float64
+	StrikePrice       float64
+	UnderlyingSymbol  string
+	OpenInterest      int64
+	OptionsType       int32
+	MiniOption        int64
+	LastSize          int64
+	Bid               float64
+	BidSize           int64
+	Ask               float64
+	AskSize           int64
+	PriceHint         int64
+	Vol24Hr           int64
+	VolAllCurrencies  int64
+	FromCurrency      string
+	LastMarket        string
+	CirculatingSupply float64
+	MarketCap         float64
+}
+
+// decodeTick decodes a PricingData protobuf message.
+func decodeTick(b []byte) (Tick, error) {
+	var t Tick
+	for len(b) > 0 {
+		key, n := binary.Uvarint(b)
+		if n <= 0 {
+			return t, errProtobuf
+		}
+		b = b[n:]
+		field, wireType := key>>3, key&7
+		var v uint64
+		var s []byte
+		switch wireType {
+		case 0: // varint
+			v, n = binary.Uvarint(b)
+			if n <= 0 {
+				return t, errProtobuf
+			}
+			b = b[n:]
+		case 1: // 64-bit
+			if len(b) < 8 {
+				return t, errProtobuf
+			}
+			v = binary.LittleEndian.Uint64(b)
+			b = b[8:]
+		case 2: // length-delimited
+			l, n := binary.Uvarint(b)
+			if n <= 0 || uint64(len(b)-n) < l {
+				return t, errProtobuf
+			}
+			s = b[n : n+int(l)]
+			b = b[n+int(l):]
+		case 5: // 32-bit
+			if len(b) < 4 {
+				return t, errProtobuf
+			}
+			v = uint64(binary.LittleEndian.Uint32(b))
+			b = b[4:]
+		default:
+			return t, errProtobuf
+		}
+
+		f32 := float64(math.Float32frombits(uint32(v)))
+		sint := int64(v>>1) ^ -int64(v&1) // zigzag decoding
+		switch field {
+		case 1:
+			t.Symbol = string(s)
+		case 2:
+			t.Price = f32
+		case 3:
+			t.Time = time.UnixMilli(sint)
+		case 4:
+			t.Currency = string(s)
+		case 5:
+			t.Exchange = string(s)
+		case 6:
+			t.QuoteType = int32(v)
+		case 7:
+			t.MarketHours = MarketHours(v)
+		case 8:
+			t.ChangePercent = f32
+		case 9:
+			t.DayVolume = sint
+		case 10:
+			t.DayHigh = f32
+		case 11:
+			t.DayLow = f32
+		case 12:
+			t.Change = f32
+		case 13:
+			t.ShortName = string(s)
+		case 14:
+			t.ExpireDate = sint
+		case 15:
+			t.OpenPrice = f32
+		case 16:
+			t.PreviousClose = f32
+		case 17:
+			t.StrikePrice = f32
+		case 18:
+			t.UnderlyingSymbol = string(s)
+		case 19:
+			t.OpenInterest = sint
+		case 20:
+			t.OptionsType = int32(v)
+		case 21:
+			t.MiniOption = sint
+		case 22:
+			t.LastSize = sint
+		case 23:
+			t.Bid = f32
+		case 24:
+			t.BidSize = sint
+		case 25:
+			t.Ask = f32
+		case 26:
+			t.AskSize = sint
+		case 27:
+			t.PriceHint = sint
+		case 28:
+			t.Vol24Hr = sint
+		case 29:
+			t.VolAllCurrencies = sint
+		case 30:
+			t.FromCurrency = string(s)
+		case 31:
+			t.LastMarket = string(s)
+		case 32:
+			t.CirculatingSupply = math.Float64frombits(v)
+		case 33:
+			t.MarketCap = math.Float64frombits(v)
+		}
+	}
+	return t, nil
+}
+
+// decodeStreamerMessage decodes a message sent by the streamer. Messages are base64-encoded PricingData,
+// either sent as is or wrapped in a JSON object of the form {"type": "pricing", "message": "..."}.
+func decodeStreamerMessage(msg []byte) (Tick, error) {
+	if len(msg) > 0 && msg[0] == '{' {
+		var wrapper struct {
+			Message string `json:"message"`
+		}
+		if err := json.Unmarshal(msg, &wrapper); err != nil {
+			return Tick{}, err
+		}
+		msg = []byte(wrapper.Message)
+	}
+	b, err := base64.StdEncoding.DecodeString(string(msg))
+	if err != nil {
+		return Tick{}, err
+	}
+	return decodeTick(b)
+}
+
+// Streamer receives real-time price updates over a WebSocket connection.
+// Symbols can be subscribed and unsubscribed at any time; they are resubscribed automatically
+// whenever the connection is re-established. A Streamer is single-use: Run reconnects by itself,
+// and calling it again returns ErrStreamerClosed.
+//
+//	s := NewStreamer()
+//	s.Subscribe("AAPL", "MSFT")
+//	go s.Run(ctx)
+//	for tick := range s.Ticks() {
+//		...
+//	}
+type Streamer struct {
+	// URL of the streamer; defaults to STREAMER. This can be pointed at a local WebSocket server for testing.
+	URL    string
+	Header http.Header
+	// MinBackoff and MaxBackoff bound the exponential delay between reconnection attempts.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// OnError, if not nil, is called with every connection or decoding error.
+	OnError func(error)
+	Verbose bool
+
+	mu      sync.Mutex
+	symbols map[string]bool
+	conn    *wsConn
+	ticks   chan Tick
+	started bool
+}
+
+// NewStreamer returns a Streamer connected to the default URL.
+func NewStreamer() *Streamer {
+	header := make(http.Header)
+	header.Set("User-Agent", YFI_USER_AGENT)
+	header.Set("Origin", "https://finance.yahoo.com")
+	return &Streamer{
+		URL:        STREAMER,
+		Header:     header,
+		MinBackoff: time.Second,
+		MaxBackoff: time.Minute,
+		symbols:    make(map[string]bool),
+		ticks:      make(chan Tick, 256),
+	}
+}
+
+// Ticks returns the channel on which decoded ticks are delivered. It is closed when Run returns.
+func (s *Streamer) Ticks() <-chan Tick {
+	return s.ticks
+}
+
+// Symbols returns the currently subscribed symbols in alphabetical order.
+func (s *Streamer) Symbols() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.symbolList()
+}
+
+func (s *Streamer) symbolList() []string {
+	res := make([]string, 0, len(s.symbols))
+	for sym := range s.symbols {
+		res = append(res, sym)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// Subscribe adds symbols to the subscription. If the Streamer is connected, the subscription takes effect immediately.
+func (s *Streamer) Subscribe(symbols ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, sym := range symbols {
+		s.symbols[sym] = true
+	}
+	if s.conn == nil {
+		return nil
+	}
+	return s.send(s.conn, "subscribe", symbols)
+}
+
+// Unsubscribe removes symbols from the subscription.
+func (s *Streamer) Unsubscribe(symbols ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, sym := range symbols {
+		delete(s.symbols, sym)
+	}
+	if s.conn == nil {
+		return nil
+	}
+	return s.send(s.conn, "unsubscribe", symbols)
+}
+
+func (s *Streamer) send(conn *wsConn, action string, symbols []string) error {
+	b, err := json.Marshal(map[string][]string{action: symbols})
+	if err != nil {
+		return err
+	}
+	return conn.WriteText(b)
+}
+
+func (s *Streamer) reportErr(err error) {
+	if s.OnError != nil {
+		s.OnError(err)
+	}
+	if s.Verbose {
+		log.Println("streamer:", err)
+	}
+}
+
+// Run connects to the streamer and delivers ticks until ctx is done, reconnecting with exponential
+// backoff whenever the connection fails. Run closes the Ticks channel and returns ctx.Err().
+// It returns ErrStreamerClosed without doing anything if Run has already been called.
+func (s *Streamer) Run(ctx context.Context) error {
+	s.mu.Lock()
+	started := s.started
+	s.started = true
+	s.mu.Unlock()
+	if started {
+		return ErrStreamerClosed
+	}
+	defer close(s.ticks)
+	backoff := s.MinBackoff
+	for {
+		connected, err := s.session(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			s.reportErr(err)
+		}
+		if connected {
+			backoff = s.MinBackoff
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > s.MaxBackoff {
+			backoff = s.MaxBackoff
+		}
+		if backoff <= 0 {
+			backoff = time.Second
+		}
+	}
+}
+
+// session runs a single connection until it fails. connected reports whether the connection was established.
+func (s *Streamer) session(ctx context.Context) (connected bool, err error) {
+	url := s.URL
+	if url == "" {
+		url = STREAMER
+	}
+	conn, err := dialWebSocket(ctx, url, s.Header)
+	if err != nil {
+		return false, err
+	}
+
+	s.mu.Lock()
+	s.conn = conn
+	if syms := s.symbolList(); len(syms) > 0 {
+		err = s.send(conn, "subscribe", syms)
+	}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.conn = nil
+		s.mu.Unlock()
+		conn.Close()
+	}()
+	if err != nil {
+		return true, err
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.conn.Close()
+		case <-done:
+		}
+	}()
+
+	for {
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			return true, err
+		}
+		tick, err := decodeStreamerMessage(msg)
+		if err != nil {
+			s.reportErr(err)
+			continue
+		}
+		select {
+		case s.ticks <- tick:
+		case <-ctx.Done():
+			return true, ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package yfi
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// EsgScoresModule represents the esgScores module of a QuoteSummary response.
+type EsgScoresModule struct {
+	MaxAge                            int64           `json:"maxAge"`
+	TotalEsg                          Value           `json:"totalEsg"`
+	EnvironmentScore                  Value           `json:"environmentScore"`
+	SocialScore                       Value           `json:"socialScore"`
+	GovernanceScore                   Value           `json:"governanceScore"`
+	RatingYear                        int             `json:"ratingYear"`
+	RatingMonth                       int             `json:"ratingMonth"`
+	HighestControversy                Value           `json:"highestControversy"`
+	PeerCount                         int             `json:"peerCount"`
+	EsgPerformance                    string          `json:"esgPerformance"`
+	PeerGroup                         string          `json:"peerGroup"`
+	RelatedControversy                []string        `json:"relatedControversy"`
+	PeerEsgScorePerformance           PeerPerformance `json:"peerEsgScorePerformance"`
+	PeerGovernancePerformance         PeerPerformance `json:"peerGovernancePerformance"`
+	PeerSocialPerformance             PeerPerformance `json:"peerSocialPerformance"`
+	PeerEnvironmentPerformance        PeerPerformance `json:"peerEnvironmentPerformance"`
+	PeerHighestControversyPerformance PeerPerformance `json:"peerHighestControversyPerformance"`
+	Percentile                        Value           `json:"percentile"`
+	EnvironmentPercentile             Value           `json:"environmentPercentile"`
+	SocialPercentile                  Value           `json:"socialPercentile"`
+	GovernancePercentile              Value           `json:"governancePercentile"`
+	Adult                             bool            `json:"adult"`
+	Alcoholic                         bool            `json:"alcoholic"`
+	AnimalTesting                     bool            `json:"animalTesting"`
+	Catholic                          bool            `json:"catholic"`
+	ControversialWeapons              bool            `json:"controversialWeapons"`
+	SmallArms                         bool            `json:"smallArms"`
+	FurLeather                        bool            `json:"furLeather"`
+	Gambling                          bool            `json:"gambling"`
+	Gmo                               bool            `json:"gmo"`
+	MilitaryContract                  bool            `json:"militaryContract"`
+	Nuclear                           bool            `json:"nuclear"`
+	Pesticides                        bool            `json:"pesticides"`
+	PalmOil                           bool            `json:"palmOil"`
+	Coal                              bool            `json:"coal"`
+	Tobacco                           bool            `json:"tobacco"`
+}
+
+// PeerPerformance describes the range of a score across an asset's peer group.
+type PeerPerformance struct {
+	Min Value `json:"min"`
+	Avg Value `json:"avg"`
+	Max Value `json:"max"`
+}
+
+// CalendarEventsModule represents the calendarEvents module of a QuoteSummary response.
+type CalendarEventsModule struct {
+	MaxAge         int64            `json:"maxAge"`
+	Earnings       EarningsEstimate `json:"earnings"`
+	ExDividendDate Value            `json:"exDividendDate"`
+	DividendDate   Value            `json:"dividendDate"`
+}
+
+// EarningsEstimate contains the expected date range and analyst estimates for an upcoming earnings report.
+type EarningsEstimate struct {
+	EarningsDate    []Value `json:"earningsDate"`
+	EarningsAverage Value   `json:"earningsAverage"`
+	EarningsLow     Value   `json:"earningsLow"`
+	EarningsHigh    Value   `json:"earningsHigh"`
+	RevenueAverage  Value   `json:"revenueAverage"`
+	RevenueLow      Value   `json:"revenueLow"`
+	RevenueHigh     Value   `json:"revenueHigh"`
+}
+
+// EarningsDates returns the earnings date range as a slice of time.Time values.
+// Yahoo provides a single date once a report is confirmed and a start and end date otherwise.
+func (e EarningsEstimate) EarningsDates() []time.Time {
+	res := make([]time.Time, len(e.EarningsDate))
+	for i := 0; i < len(e.EarningsDate); i++ {
+		res[i] = e.EarningsDate[i].Time()
+	}
+	return res
+}
+
+// SecFilingsModule represents the secFilings module of a QuoteSummary response.
+type SecFilingsModule struct {
+	MaxAge  int64       `json:"maxAge"`
+	Filings []SecFiling `json:"filings"`
+}
+
+// SecFiling represents a single filing made with the SEC.
+type SecFiling struct {
+	Date      string       `json:"date"`
+	EpochDate int64        `json:"epochDate"`
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	EdgarUrl  string       `json:"edgarUrl"`
+	Exhibits  []SecExhibit `json:"exhibits"`
+	MaxAge    int64        `json:"maxAge"`
+}
+
+// SecExhibit represents a document attached to a SecFiling.
+type SecExhibit struct {
+	Type        string `json:"type"`
+	Url         string `json:"url"`
+	DownloadUrl string `json:"downloadUrl"`
+}
+
+// DecodeModule decodes the module corresponding to q from a QuoteSummary response into v.
+// ErrMissingModule is returned if the module is not present in the summary.
+func DecodeModule(summary map[string]any, q QuoteParam, v any) error {
+	m, ok := summary[string(q)]
+	if !ok {
+		return ErrMissingModule
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// GetEsgScores retrieves the esgScores module for a given symbol.
+func (c *Client) GetEsgScores(symbol string) (EsgScoresModule, error) {
+	var res EsgScoresModule
+	err := c.getModule(symbol, EsgScores, &res)
+	return res, err
+}
+
+// GetCalendarEvents retrieves the calendarEvents module for a given symbol.
+func (c *Client) GetCalendarEvents(symbol string) (CalendarEventsModule, error) {
+	var res CalendarEventsModule
+	err := c.getModule(symbol, CalendarEvents, &res)
+	return res, err
+}
+
+// GetSecFilings retrieves the secFilings module for a given symbol.
+func (c *Client) GetSecFilings(symbol string) (SecFilingsModule, error) {
+	var res SecFilingsModule
+	err := c.getModule(symbol, SecFilings, &res)
+	return res, err
+}
+
+func (c *Client) getModule(symbol string, q QuoteParam, v any) error {
+	summary, err := c.GetQuoteSummary(symbol, []QuoteParam{q})
+	if err != nil {
+		return err
+	}
+	return DecodeModule(summary, q, v)
+}
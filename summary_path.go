@@ -0,0 +1,208 @@
+package yfi
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PathError records the path and segment that caused a QuoteSummary lookup to fail.
+type PathError struct {
+	Path    string
+	Segment string
+	Err     error
+}
+
+func (e *PathError) Error() string {
+	return "path " + strconv.Quote(e.Path) + ": segment " + strconv.Quote(e.Segment) + ": " + e.Err.Error()
+}
+
+func (e *PathError) Unwrap() error {
+	return e.Err
+}
+
+// Get returns the value found at path. Path segments are separated by periods,
+// and list elements are addressed either by index in brackets or as a numeric segment,
+// e.g. "secFilings.filings[0].type" or "secFilings.filings.0.type".
+func (s QuoteSummary) Get(path string) (any, error) {
+	segments, err := splitPath(path)
+	if err != nil {
+		return nil, err
+	}
+	var cur any = map[string]any(s)
+	for _, seg := range segments {
+		switch v := cur.(type) {
+		case map[string]any:
+			next, ok := v[seg]
+			if !ok {
+				return nil, &PathError{path, seg, ErrPathNotFound}
+			}
+			cur = next
+		case []any:
+			i, err := strconv.Atoi(seg)
+			if err != nil {
+				return nil, &PathError{path, seg, ErrPathType}
+			}
+			if i < 0 || i >= len(v) {
+				return nil, &PathError{path, seg, ErrPathNotFound}
+			}
+			cur = v[i]
+		case nil:
+			return nil, &PathError{path, seg, ErrPathNotFound}
+		default:
+			return nil, &PathError{path, seg, ErrPathType}
+		}
+	}
+	return cur, nil
+}
+
+// Has reports whether a non-null value exists at path.
+func (s QuoteSummary) Has(path string) bool {
+	v, err := s.Get(path)
+	return err == nil && v != nil
+}
+
+// Float returns the number at path. If path refers to a {raw, fmt} object, its raw value is returned.
+func (s QuoteSummary) Float(path string) (float64, error) {
+	v, err := s.Value(path)
+	if err != nil {
+		return 0, err
+	}
+	if !v.Valid {
+		return 0, &PathError{path, lastSegment(path), ErrPathNotFound}
+	}
+	return v.Raw, nil
+}
+
+// Int returns the number at path truncated to an int64.
+func (s QuoteSummary) Int(path string) (int64, error) {
+	f, err := s.Float(path)
+	return int64(f), err
+}
+
+// Time returns the Unix timestamp at path as a time.Time.
+func (s QuoteSummary) Time(path string) (time.Time, error) {
+	f, err := s.Float(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(int64(f), 0), nil
+}
+
+// Value returns the number at path as a Value. Unlike Float, a null or empty
+// value is not an error; the returned Value is simply not Valid.
+func (s QuoteSummary) Value(path string) (Value, error) {
+	var res Value
+	v, err := s.Get(path)
+	if err != nil {
+		return res, err
+	}
+	switch v.(type) {
+	case nil, float64, map[string]any:
+	default:
+		return res, &PathError{path, lastSegment(path), ErrPathType}
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return res, err
+	}
+	if err = json.Unmarshal(b, &res); err != nil {
+		return res, &PathError{path, lastSegment(path), ErrPathType}
+	}
+	return res, nil
+}
+
+// String returns the string at path.
+func (s QuoteSummary) String(path string) (string, error) {
+	v, err := s.Get(path)
+	if err != nil {
+		return "", err
+	}
+	str, ok := v.(string)
+	if !ok {
+		return "", &PathError{path, lastSegment(path), ErrPathType}
+	}
+	return str, nil
+}
+
+// Bool returns the boolean at path.
+func (s QuoteSummary) Bool(path string) (bool, error) {
+	v, err := s.Get(path)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, &PathError{path, lastSegment(path), ErrPathType}
+	}
+	return b, nil
+}
+
+// List returns the list at path.
+func (s QuoteSummary) List(path string) ([]any, error) {
+	v, err := s.Get(path)
+	if err != nil {
+		return nil, err
+	}
+	l, ok := v.([]any)
+	if !ok {
+		return nil, &PathError{path, lastSegment(path), ErrPathType}
+	}
+	return l, nil
+}
+
+// Map returns the object at path.
+func (s QuoteSummary) Map(path string) (map[string]any, error) {
+	v, err := s.Get(path)
+	if err != nil {
+		return nil, err
+	}
+	m, ok := v.(map[string]any)
+	if !ok {
+		return nil, &PathError{path, lastSegment(path), ErrPathType}
+	}
+	return m, nil
+}
+
+// splitPath converts a path such as "a.b[0].c" into the segments a, b, 0, c.
+func splitPath(path string) ([]string, error) {
+	if path == "" {
+		return nil, &PathError{path, "", ErrPathSyntax}
+	}
+	var res []string
+	for _, part := range strings.Split(path, ".") {
+		name := part
+		var indices []string
+		if i := strings.IndexByte(part, '['); i >= 0 {
+			name = part[:i]
+			rest := part[i:]
+			for len(rest) > 0 {
+				end := strings.IndexByte(rest, ']')
+				if rest[0] != '[' || end < 0 {
+					return nil, &PathError{path, part, ErrPathSyntax}
+				}
+				idx := rest[1:end]
+				if _, err := strconv.Atoi(idx); err != nil {
+					return nil, &PathError{path, part, ErrPathSyntax}
+				}
+				indices = append(indices, idx)
+				rest = rest[end+1:]
+			}
+		}
+		if name == "" {
+			return nil, &PathError{path, part, ErrPathSyntax}
+		}
+		res = append(res, name)
+		res = append(res, indices...)
+	}
+	return res, nil
+}
+
+func lastSegment(path string) string {
+	segments, err := splitPath(path)
+	if err != nil || len(segments) == 0 {
+		return path
+	}
+	return segments[len(segments)-1]
+}
@@ -1,14 +1,18 @@
 package yfi
 
 import (
+	"bytes"
 	"context"
 	"encoding/csv"
 	"encoding/json"
 	"errors"
+	"io"
 	"log"
+	"math"
 	"net/http"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -50,27 +54,238 @@ func (t *Ticker) ToCsv(filename string) error {
 		return err
 	}
 	defer f.Close()
-	w := csv.NewWriter(f)
-	err = w.Write([]string{"Date", "Open", "High", "Low", "Close", "Adj Close", "Volume"})
+	err = t.WriteCSV(f, DefaultExportOptions())
 	if err != nil {
 		return err
 	}
+	return f.Close()
+}
+
+// Load Ticker history from a .json file written by ToJson
+func (t *Ticker) FromJson(filename string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return t.ReadJSON(f)
+}
+
+// Load Ticker history from a .csv file written by ToCsv or downloaded from Yahoo Finance
+func (t *Ticker) FromCsv(filename string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return t.ReadCSV(f)
+}
+
+// ReadJSON decodes a Ticker from r, encoded either by ToJson or by WriteJSON in the ColumnJSON layout with the default
+// headers, which is what the zero ExportOptions write. The history columns must all have the same length. In the
+// ColumnJSON layout, dates may use any DateFormat, null prices are read as NaN, the close is used if Adj Close is
+// missing, and the Symbol and Interval columns, if present, must each hold a single value. Err is not restored.
+func (t *Ticker) ReadJSON(r io.Reader) error {
+	var v struct {
+		Ticker
+		Err json.RawMessage // errors cannot be decoded
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	var cols map[string]json.RawMessage
+	if json.Unmarshal(b, &cols) == nil && cols[string(ColDate)] != nil {
+		if err = v.Ticker.readColumnJSON(cols); err != nil {
+			return err
+		}
+	} else if err = json.NewDecoder(bytes.NewReader(b)).Decode(&v); err != nil {
+		return err
+	}
+	if err = v.Ticker.validate(); err != nil {
+		return err
+	}
+	*t = v.Ticker
+	return nil
+}
+
+// readColumnJSON sets the history of t from the columns of a ColumnJSON export.
+func (t *Ticker) readColumnJSON(cols map[string]json.RawMessage) error {
+	for _, col := range []Column{ColOpen, ColHigh, ColLow, ColClose, ColVolume} {
+		if cols[string(col)] == nil {
+			return ErrMalformedData
+		}
+	}
+	if cols[string(ColAdjClose)] == nil {
+		cols[string(ColAdjClose)] = cols[string(ColClose)]
+	}
+	var dates []json.RawMessage
+	if err := json.Unmarshal(cols[string(ColDate)], &dates); err != nil {
+		return err
+	}
+	t.HistoricDates = make([]int64, len(dates))
+	for i, d := range dates {
+		s := string(d)
+		json.Unmarshal(d, &s) // dates other than Unix seconds are strings
+		var err error
+		if t.HistoricDates[i], err = parseCSVDate(s); err != nil {
+			return ErrMalformedData
+		}
+	}
+	for _, c := range []struct {
+		col Column
+		dst *[]float64
+	}{
+		{ColOpen, &t.HistoricOpen}, {ColHigh, &t.HistoricHigh}, {ColLow, &t.HistoricLow},
+		{ColClose, &t.HistoricClose}, {ColAdjClose, &t.HistoricAdjClose},
+	} {
+		var vals []*float64
+		if err := json.Unmarshal(cols[string(c.col)], &vals); err != nil {
+			return err
+		}
+		*c.dst = make([]float64, len(vals))
+		for i, v := range vals {
+			(*c.dst)[i] = math.NaN()
+			if v != nil {
+				(*c.dst)[i] = *v
+			}
+		}
+	}
+	var vols []float64 // some exports format volumes as floats
+	if err := json.Unmarshal(cols[string(ColVolume)], &vols); err != nil {
+		return err
+	}
+	t.HistoricVolume = make([]int, len(vols))
+	for i, v := range vols {
+		t.HistoricVolume[i] = int(v)
+	}
+	for _, c := range []struct {
+		col Column
+		dst *string
+	}{{ColSymbol, &t.Symbol}, {ColInterval, (*string)(&t.Interval)}} {
+		if cols[string(c.col)] == nil {
+			continue
+		}
+		var vals []string
+		if err := json.Unmarshal(cols[string(c.col)], &vals); err != nil {
+			return err
+		}
+		for _, v := range vals {
+			if v != vals[0] {
+				return ErrMalformedData
+			}
+		}
+		if len(vals) > 0 {
+			*c.dst = vals[0]
+		}
+	}
+	return nil
+}
 
-	for i := 0; i < len(t.HistoricDates); i++ {
-		d := strconv.Itoa(int(t.HistoricDates[i])) //int(t.HistoricDates[i].Unix()))
-		op := strconv.FormatFloat(t.HistoricOpen[i], 'f', -1, 64)
-		hi := strconv.FormatFloat(t.HistoricHigh[i], 'f', -1, 64)
-		lo := strconv.FormatFloat(t.HistoricLow[i], 'f', -1, 64)
-		cl := strconv.FormatFloat(t.HistoricClose[i], 'f', -1, 64)
-		adjC := strconv.FormatFloat(t.HistoricAdjClose[i], 'f', -1, 64)
-		vol := strconv.Itoa(t.HistoricVolume[i])
+// validate checks that the history columns of t all have the same length.
+func (t *Ticker) validate() error {
+	n := len(t.HistoricDates)
+	if len(t.HistoricOpen) != n || len(t.HistoricHigh) != n || len(t.HistoricLow) != n ||
+		len(t.HistoricClose) != n || len(t.HistoricAdjClose) != n || len(t.HistoricVolume) != n {
+		return ErrColumnLength
+	}
+	return nil
+}
+
+// ReadCSV replaces the history of t with the rows of a CSV file read from r. The file must have a header
+// row naming its columns, as written by ToCsv or downloaded from Yahoo Finance; Date, Open, High, Low,
+// Close and Volume are required, and the close is used if Adj Close is missing. Dates may be Unix
+// seconds, dates (2006-01-02) or RFC 3339 times. Rows containing null values, which Yahoo Finance
+// includes for days without trading, are skipped. Rows with a different number of fields than the header
+// are reported as a *csv.ParseError wrapping csv.ErrFieldCount, and rows with invalid values as one wrapping ErrMalformedData.
+func (t *Ticker) ReadCSV(r io.Reader) error {
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if err != nil {
+		return err
+	}
+	cols := map[string]int{"Adj Close": -1}
+	for i, name := range header {
+		cols[strings.TrimSpace(name)] = i
+	}
+	for _, name := range []string{"Date", "Open", "High", "Low", "Close", "Volume"} {
+		if _, ok := cols[name]; !ok {
+			return &csv.ParseError{StartLine: 1, Line: 1, Err: ErrMalformedData}
+		}
+	}
+	adj := cols["Adj Close"]
+	if adj < 0 {
+		adj = cols["Close"]
+	}
 
-		err = w.Write([]string{d, op, hi, lo, cl, adjC, vol})
+	var h Ticker
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
 		if err != nil {
 			return err
 		}
+		line, _ := cr.FieldPos(0)
+		parseErr := &csv.ParseError{StartLine: line, Line: line, Err: ErrMalformedData}
+		null := false
+		for _, field := range record {
+			if field == "null" || field == "" {
+				null = true
+			}
+		}
+		if null {
+			continue
+		}
+
+		date, err := parseCSVDate(record[cols["Date"]])
+		if err != nil {
+			return parseErr
+		}
+		var vals [5]float64
+		for i, col := range []int{cols["Open"], cols["High"], cols["Low"], cols["Close"], adj} {
+			if vals[i], err = strconv.ParseFloat(record[col], 64); err != nil {
+				return parseErr
+			}
+		}
+		vol, err := strconv.Atoi(record[cols["Volume"]])
+		if err != nil {
+			// some downloads format volumes as floats
+			f, ferr := strconv.ParseFloat(record[cols["Volume"]], 64)
+			if ferr != nil {
+				return parseErr
+			}
+			vol = int(f)
+		}
+		h.HistoricDates = append(h.HistoricDates, date)
+		h.HistoricOpen = append(h.HistoricOpen, vals[0])
+		h.HistoricHigh = append(h.HistoricHigh, vals[1])
+		h.HistoricLow = append(h.HistoricLow, vals[2])
+		h.HistoricClose = append(h.HistoricClose, vals[3])
+		h.HistoricAdjClose = append(h.HistoricAdjClose, vals[4])
+		h.HistoricVolume = append(h.HistoricVolume, vol)
+	}
+	t.HistoricDates = h.HistoricDates
+	t.HistoricOpen = h.HistoricOpen
+	t.HistoricHigh = h.HistoricHigh
+	t.HistoricLow = h.HistoricLow
+	t.HistoricClose = h.HistoricClose
+	t.HistoricAdjClose = h.HistoricAdjClose
+	t.HistoricVolume = h.HistoricVolume
+	return nil
+}
+
+func parseCSVDate(s string) (int64, error) {
+	if d, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return d, nil
 	}
-	return err
+	for _, layout := range []string{"2006-01-02", time.RFC3339, "2006-01-02 15:04:05-07:00"} {
+		if d, err := time.Parse(layout, s); err == nil {
+			return d.Unix(), nil
+		}
+	}
+	return 0, ErrMalformedData
 }
 
 // Retrieve historical data for a given ticker.
@@ -149,12 +364,11 @@ func (c *Client) GetTicker(symbol string, interval TimeSpan, startDate, endDate
 	res.HistoricHigh = make([]float64, lr)
 	res.HistoricVolume = make([]int, lr)
 
+	// the header row has already been discarded
 	for i, record := range records {
-		if i > 0 {
-			err = res.parseCSVRecord(i, record)
-			if err != nil {
-				return res, err
-			}
+		err = res.parseCSVRecord(i, record)
+		if err != nil {
+			return res, err
 		}
 	}
 	return res, nil
@@ -290,12 +504,11 @@ func (c *Client) burstTickerHist(symbol string, interval TimeSpan, startDate, en
 	res.HistoricHigh = make([]float64, lr)
 	res.HistoricVolume = make([]int, lr)
 
+	// the header row has already been discarded
 	for i, record := range records {
-		if i > 0 {
-			err = res.parseCSVRecord(i, record)
-			if err != nil {
-				return burstResp{&res, index}
-			}
+		err = res.parseCSVRecord(i, record)
+		if err != nil {
+			return burstResp{&res, index}
 		}
 	}
 	return burstResp{&res, index}
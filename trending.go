@@ -0,0 +1,79 @@
+package yfi
+
+import (
+	"strconv"
+)
+
+// ScoredSymbol is a symbol returned by GetTrending or GetRecommendedSymbols.
+// Quote is nil until the symbol is passed to HydrateQuotes.
+type ScoredSymbol struct {
+	Symbol string  `json:"symbol"`
+	Score  float64 `json:"score"`
+	Quote  *Quote  `json:"-"`
+}
+
+type outerTrendingResp struct {
+	Finance trendingResp `json:"finance"`
+}
+
+type trendingResp struct {
+	Result []trendingResult `json:"result"`
+	Error  any              `json:"error"`
+}
+
+type trendingResult struct {
+	Count              int            `json:"count"`
+	Quotes             []ScoredSymbol `json:"quotes"`
+	Symbol             string         `json:"symbol"`
+	RecommendedSymbols []ScoredSymbol `json:"recommendedSymbols"`
+}
+
+// GetTrending returns up to count of the symbols currently trending in region, e.g. "US".
+// A count less than 1 leaves the choice to Yahoo.
+func (c *Client) GetTrending(region string, count int) ([]ScoredSymbol, error) {
+	url := V1 + "trending/" + region
+	if count > 0 {
+		url += "?count=" + strconv.Itoa(count)
+	}
+	var v outerTrendingResp
+	err := c.getJSON(url, &v)
+	if err != nil {
+		return nil, err
+	}
+	if len(v.Finance.Result) == 0 {
+		return nil, ErrMalformedResp
+	}
+	return v.Finance.Result[0].Quotes, nil
+}
+
+// GetRecommendedSymbols returns symbols that Yahoo considers similar to symbol, ordered by descending score.
+func (c *Client) GetRecommendedSymbols(symbol string) ([]ScoredSymbol, error) {
+	var v outerTrendingResp
+	err := c.getJSON(V6+"recommendationsbysymbol/"+symbol, &v)
+	if err != nil {
+		return nil, err
+	}
+	if len(v.Finance.Result) == 0 {
+		return nil, ErrMalformedResp
+	}
+	return v.Finance.Result[0].RecommendedSymbols, nil
+}
+
+// HydrateQuotes retrieves the Quote of each of symbols through GetQuotes and stores it in the ScoredSymbol.
+// Symbols for which Yahoo returns no Quote are left with a nil Quote.
+func (c *Client) HydrateQuotes(symbols []ScoredSymbol) error {
+	names := make([]string, len(symbols))
+	for i := 0; i < len(symbols); i++ {
+		names[i] = symbols[i].Symbol
+	}
+	quotes, err := c.GetQuotes(names)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < len(symbols); i++ {
+		if q, ok := quotes[symbols[i].Symbol]; ok {
+			symbols[i].Quote = &q
+		}
+	}
+	return nil
+}
@@ -1,18 +1,87 @@
 package yfi
 
-type yfiTime struct {
-	Raw int64  `json:"raw"`
-	Fmt string `json:"fmt"`
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+)
+
+// Value represents a numeric value returned by the Yahoo Finance API.
+// Most endpoints format numbers as {"raw": ..., "fmt": ..., "longFmt": ...} objects,
+// but Value also accepts bare numbers, empty objects and nulls. Valid reports
+// whether a value was actually present in the response.
+type Value struct {
+	Raw     float64
+	Fmt     string
+	LongFmt string
+	Valid   bool
+}
+
+type rawValue struct {
+	Raw     *float64 `json:"raw,omitempty"`
+	Fmt     string   `json:"fmt,omitempty"`
+	LongFmt string   `json:"longFmt,omitempty"`
+}
+
+func (v *Value) UnmarshalJSON(b []byte) error {
+	*v = Value{}
+	b = bytes.TrimSpace(b)
+	if len(b) == 0 || bytes.Equal(b, []byte("null")) {
+		return nil
+	}
+	if b[0] != '{' {
+		err := json.Unmarshal(b, &v.Raw)
+		if err != nil {
+			return err
+		}
+		v.Valid = true
+		return nil
+	}
+	var rv rawValue
+	err := json.Unmarshal(b, &rv)
+	if err != nil {
+		return err
+	}
+	v.Fmt = rv.Fmt
+	v.LongFmt = rv.LongFmt
+	if rv.Raw != nil {
+		v.Raw = *rv.Raw
+		v.Valid = true
+	}
+	return nil
 }
 
-type yfiFloat struct {
-	Raw float64 `json:"raw"`
-	Fmt string  `json:"fmt"`
+// MarshalJSON encodes v in the same {"raw": ..., "fmt": ..., "longFmt": ...} form
+// used by the Yahoo Finance API. Values that are not Valid are encoded as null.
+func (v Value) MarshalJSON() ([]byte, error) {
+	if !v.Valid {
+		return []byte("null"), nil
+	}
+	raw := v.Raw
+	return json.Marshal(rawValue{Raw: &raw, Fmt: v.Fmt, LongFmt: v.LongFmt})
 }
 
-/*
-type yfiLongFloat struct {
-	Raw     float64 `json:"raw"`
-	Fmt     string  `json:"fmt"`
-	LongFmt string  `json:"longFmt"`
-} */
+// Int returns the raw value truncated to an int64.
+func (v Value) Int() int64 {
+	return int64(v.Raw)
+}
+
+// Time interprets the raw value as a Unix timestamp in seconds.
+// The zero time.Time is returned if v is not Valid.
+func (v Value) Time() time.Time {
+	if !v.Valid {
+		return time.Time{}
+	}
+	return time.Unix(int64(v.Raw), 0)
+}
+
+func (v Value) String() string {
+	if v.Fmt != "" {
+		return v.Fmt
+	}
+	if !v.Valid {
+		return ""
+	}
+	b, _ := json.Marshal(v.Raw)
+	return string(b)
+}
@@ -0,0 +1,280 @@
+package yfi
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// SurfacePoint is a single implied volatility observation of a VolSurface.
+// Moneyness is the ratio of the Strike to the price of the underlying asset.
+type SurfacePoint struct {
+	Expiration        int64      `json:"expiration"`
+	Expiry            float64    `json:"expiry"`
+	Strike            float64    `json:"strike"`
+	Moneyness         float64    `json:"moneyness"`
+	Type              OptionType `json:"type"`
+	ImpliedVolatility float64    `json:"impliedVolatility"`
+}
+
+// SurfaceOptions control how a VolSurface is built from option chains.
+type SurfaceOptions struct {
+	Model         Model
+	Rate          float64
+	DividendYield float64
+	// UseYahooIV uses the ImpliedVolatility reported by Yahoo instead of solving for it from each contract's Mid price.
+	UseYahooIV bool
+	// IncludeITM includes in-the-money contracts. By default, only out-of-the-money
+	// puts and calls are used, as they are usually more liquid.
+	IncludeITM bool
+	// MinOpenInterest excludes contracts with less open interest.
+	MinOpenInterest int
+}
+
+// VolSurface is an implied volatility surface, indexed by strike and time to expiration.
+// Points are sorted by Expiration and then by Strike.
+type VolSurface struct {
+	Symbol     string         `json:"symbol"`
+	Underlying float64        `json:"underlying"`
+	AsOf       time.Time      `json:"asOf"`
+	Points     []SurfacePoint `json:"points"`
+}
+
+// Smile contains the implied volatilities of a single expiration date.
+// Skew is the difference between the implied volatilities at 90% and 110% moneyness.
+type Smile struct {
+	Expiration int64
+	Expiry     float64
+	ATMVol     float64
+	Skew       float64
+	Points     []SurfacePoint
+}
+
+// TermPoint is a single point of the at-the-money term structure.
+type TermPoint struct {
+	Expiration int64
+	Expiry     float64
+	ATMVol     float64
+}
+
+// BuildVolSurface builds a VolSurface from the option chains of a single underlying asset, e.g. those returned by GetOptionChains.
+// The price of the underlying is taken from the Quote of the first chain. Contracts that have expired,
+// or whose implied volatility cannot be determined, are skipped.
+func BuildVolSurface(chains []OptionChain, opts SurfaceOptions, now time.Time) (VolSurface, error) {
+	var res VolSurface
+	res.AsOf = now
+	if len(chains) == 0 {
+		return res, ErrEmptySurface
+	}
+	res.Symbol = chains[0].UnderlyingSymbol
+	res.Underlying = chains[0].Quote.RegularMarketPrice
+	if res.Underlying <= 0 {
+		return res, ErrEmptySurface
+	}
+	for _, chain := range chains {
+		for _, contracts := range [][]OptionContract{chain.Calls, chain.Puts} {
+			for _, o := range contracts {
+				if !opts.IncludeITM && o.InTheMoney {
+					continue
+				}
+				if o.OpenInterest < opts.MinOpenInterest {
+					continue
+				}
+				p := o.Params(res.Underlying, opts.Rate, opts.DividendYield, now)
+				if p.Expiry <= 0 {
+					continue
+				}
+				iv := o.ImpliedVolatility
+				if !opts.UseYahooIV {
+					var err error
+					iv, err = opts.Model.ImpliedVolatility(p, o.Mid())
+					if err != nil {
+						continue
+					}
+				}
+				if iv <= 0 || math.IsNaN(iv) {
+					continue
+				}
+				res.Points = append(res.Points, SurfacePoint{
+					Expiration:        o.Expiration,
+					Expiry:            p.Expiry,
+					Strike:            o.Strike,
+					Moneyness:         o.Strike / res.Underlying,
+					Type:              p.Type,
+					ImpliedVolatility: iv,
+				})
+			}
+		}
+	}
+	if len(res.Points) == 0 {
+		return res, ErrEmptySurface
+	}
+	sort.Slice(res.Points, func(i, j int) bool {
+		if res.Points[i].Expiration != res.Points[j].Expiration {
+			return res.Points[i].Expiration < res.Points[j].Expiration
+		}
+		if res.Points[i].Strike != res.Points[j].Strike {
+			return res.Points[i].Strike < res.Points[j].Strike
+		}
+		return res.Points[i].Type < res.Points[j].Type
+	})
+	return res, nil
+}
+
+// smileSlice holds the strikes and volatilities of a single expiration date, averaging calls and puts with the same strike.
+type smileSlice struct {
+	expiration int64
+	expiry     float64
+	strikes    []float64
+	vols       []float64
+	points     []SurfacePoint
+}
+
+func (s VolSurface) slices() []smileSlice {
+	var res []smileSlice
+	for i := 0; i < len(s.Points); {
+		sl := smileSlice{expiration: s.Points[i].Expiration, expiry: s.Points[i].Expiry}
+		j := i
+		for ; j < len(s.Points) && s.Points[j].Expiration == sl.expiration; j++ {
+			p := s.Points[j]
+			n := len(sl.strikes)
+			if n > 0 && sl.strikes[n-1] == p.Strike {
+				sl.vols[n-1] = (sl.vols[n-1] + p.ImpliedVolatility) / 2
+				continue
+			}
+			sl.strikes = append(sl.strikes, p.Strike)
+			sl.vols = append(sl.vols, p.ImpliedVolatility)
+		}
+		sl.points = s.Points[i:j]
+		res = append(res, sl)
+		i = j
+	}
+	return res
+}
+
+// interpolate linearly interpolates ys at x, extrapolating flat beyond the first and last xs.
+func interpolate(xs, ys []float64, x float64) float64 {
+	n := len(xs)
+	if x <= xs[0] {
+		return ys[0]
+	}
+	if x >= xs[n-1] {
+		return ys[n-1]
+	}
+	i := sort.SearchFloat64s(xs, x)
+	w := (x - xs[i-1]) / (xs[i] - xs[i-1])
+	return ys[i-1] + w*(ys[i]-ys[i-1])
+}
+
+func (sl smileSlice) vol(strike float64) float64 {
+	return interpolate(sl.strikes, sl.vols, strike)
+}
+
+// Vol returns the implied volatility at strike for a time to expiration in years.
+// Volatilities are interpolated linearly in strike within each expiration date and
+// linearly in total variance between expiration dates, and are extrapolated flat.
+func (s VolSurface) Vol(expiry, strike float64) (float64, error) {
+	sls := s.slices()
+	if len(sls) == 0 {
+		return 0, ErrEmptySurface
+	}
+	if expiry <= sls[0].expiry {
+		return sls[0].vol(strike), nil
+	}
+	last := sls[len(sls)-1]
+	if expiry >= last.expiry {
+		return last.vol(strike), nil
+	}
+	i := sort.Search(len(sls), func(i int) bool { return sls[i].expiry >= expiry })
+	lo, hi := sls[i-1], sls[i]
+	loVar := lo.vol(strike) * lo.vol(strike) * lo.expiry
+	hiVar := hi.vol(strike) * hi.vol(strike) * hi.expiry
+	w := (expiry - lo.expiry) / (hi.expiry - lo.expiry)
+	totalVar := loVar + w*(hiVar-loVar)
+	if totalVar <= 0 {
+		return 0, nil
+	}
+	return math.Sqrt(totalVar / expiry), nil
+}
+
+// VolAtMoneyness is like Vol, but takes the ratio of the strike to the underlying price.
+func (s VolSurface) VolAtMoneyness(expiry, moneyness float64) (float64, error) {
+	return s.Vol(expiry, moneyness*s.Underlying)
+}
+
+// Smiles returns the implied volatilities of each expiration date, sorted by Expiration.
+func (s VolSurface) Smiles() []Smile {
+	sls := s.slices()
+	res := make([]Smile, len(sls))
+	for i, sl := range sls {
+		res[i] = Smile{
+			Expiration: sl.expiration,
+			Expiry:     sl.expiry,
+			ATMVol:     sl.vol(s.Underlying),
+			Skew:       sl.vol(0.9*s.Underlying) - sl.vol(1.1*s.Underlying),
+			Points:     sl.points,
+		}
+	}
+	return res
+}
+
+// TermStructure returns the at-the-money implied volatility of each expiration date.
+func (s VolSurface) TermStructure() []TermPoint {
+	smiles := s.Smiles()
+	res := make([]TermPoint, len(smiles))
+	for i, sm := range smiles {
+		res[i] = TermPoint{Expiration: sm.Expiration, Expiry: sm.Expiry, ATMVol: sm.ATMVol}
+	}
+	return res
+}
+
+// Grid returns the interpolated implied volatilities for each combination of expiry and moneyness,
+// indexed as grid[expiry][moneyness]. This is useful for plotting the surface as a heat map or mesh.
+func (s VolSurface) Grid(expiries, moneyness []float64) ([][]float64, error) {
+	res := make([][]float64, len(expiries))
+	for i, e := range expiries {
+		res[i] = make([]float64, len(moneyness))
+		for j, m := range moneyness {
+			v, err := s.VolAtMoneyness(e, m)
+			if err != nil {
+				return res, err
+			}
+			res[i][j] = v
+		}
+	}
+	return res, nil
+}
+
+// WriteCSV writes the Points of the surface to w as CSV, one row per point.
+func (s VolSurface) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	err := cw.Write([]string{"Expiration", "Expiry", "Strike", "Moneyness", "Type", "Implied Volatility"})
+	if err != nil {
+		return err
+	}
+	for _, p := range s.Points {
+		err = cw.Write([]string{
+			strconv.FormatInt(p.Expiration, 10),
+			strconv.FormatFloat(p.Expiry, 'f', -1, 64),
+			strconv.FormatFloat(p.Strike, 'f', -1, 64),
+			strconv.FormatFloat(p.Moneyness, 'f', -1, 64),
+			p.Type.String(),
+			strconv.FormatFloat(p.ImpliedVolatility, 'f', -1, 64),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteJSON writes the surface to w as JSON.
+func (s VolSurface) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	return enc.Encode(s)
+}
@@ -0,0 +1,319 @@
+package yfi
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// QuoteChange is a set of flags describing how a Quote changed between two polls.
+type QuoteChange int
+
+const (
+	PriceChanged QuoteChange = 1 << iota
+	VolumeChanged
+	BidAskChanged
+	MarketStateChanged
+)
+
+// Has reports whether all of the flags in f are set.
+func (ch QuoteChange) Has(f QuoteChange) bool {
+	return ch&f == f
+}
+
+// QuoteEvent describes the changes to a symbol's Quote between two successive polls.
+type QuoteEvent struct {
+	Symbol   string
+	Previous Quote
+	Current  Quote
+	Changes  QuoteChange
+	Time     time.Time
+}
+
+func diffQuotes(prev, cur Quote) QuoteChange {
+	var ch QuoteChange
+	if prev.RegularMarketPrice != cur.RegularMarketPrice || prev.PostMarketPrice != cur.PostMarketPrice {
+		ch |= PriceChanged
+	}
+	if prev.RegularMarketVolume != cur.RegularMarketVolume {
+		ch |= VolumeChanged
+	}
+	if prev.Bid != cur.Bid || prev.Ask != cur.Ask || prev.BidSize != cur.BidSize || prev.AskSize != cur.AskSize {
+		ch |= BidAskChanged
+	}
+	if prev.MarketState != cur.MarketState {
+		ch |= MarketStateChanged
+	}
+	return ch
+}
+
+type watchSubscriber struct {
+	symbols map[string]bool // nil means every symbol
+	ch      chan QuoteEvent
+	fn      func(QuoteEvent)
+	quoteFn func(Quote, time.Time)
+	done    chan struct{} // closed when the subscription is cancelled
+	sending *sync.Mutex   // held while sending to ch, which is closed once done is
+}
+
+func (s watchSubscriber) cancelled() bool {
+	select {
+	case <-s.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// send sends ev to s.ch unless the subscription is cancelled first. It returns ctx.Err() if ctx is done first.
+func (s watchSubscriber) send(ctx context.Context, ev QuoteEvent) error {
+	s.sending.Lock()
+	defer s.sending.Unlock()
+	if s.cancelled() {
+		return nil
+	}
+	select {
+	case s.ch <- ev:
+	case <-s.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return nil
+}
+
+func (s watchSubscriber) wants(symbol string) bool {
+	return s.symbols == nil || s.symbols[strings.ToUpper(symbol)]
+}
+
+// Watcher polls GetQuotes on a schedule and notifies subscribers whenever a symbol's Quote changes.
+// Polling slows down to ClosedInterval while none of the watched symbols' markets are trading.
+// No events are sent for a symbol's first Quote, which serves as the baseline for later polls.
+type Watcher struct {
+	Client         *Client
+	Interval       time.Duration
+	ClosedInterval time.Duration
+	// OnError, if not nil, is called with every polling error.
+	OnError func(error)
+
+	mu      sync.Mutex
+	symbols map[string]bool
+	last    map[string]Quote
+	subs    map[int]watchSubscriber
+	nextSub int
+}
+
+// NewWatcher returns a Watcher that polls symbols every interval, but at most once a second, while their markets
+// are trading, and every 5 minutes otherwise.
+func NewWatcher(c *Client, symbols []string, interval time.Duration) *Watcher {
+	w := &Watcher{
+		Client:         c,
+		Interval:       interval,
+		ClosedInterval: 5 * time.Minute,
+		symbols:        make(map[string]bool),
+		last:           make(map[string]Quote),
+		subs:           make(map[int]watchSubscriber),
+	}
+	w.Add(symbols...)
+	return w
+}
+
+// Add starts watching symbols. Symbols are case-insensitive.
+func (w *Watcher) Add(symbols ...string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, s := range symbols {
+		w.symbols[strings.ToUpper(s)] = true
+	}
+}
+
+// Remove stops watching symbols.
+func (w *Watcher) Remove(symbols ...string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, s := range symbols {
+		s = strings.ToUpper(s)
+		delete(w.symbols, s)
+		delete(w.last, s)
+	}
+}
+
+func (w *Watcher) subscribe(sub watchSubscriber, symbols []string) func() {
+	if len(symbols) > 0 {
+		sub.symbols = make(map[string]bool, len(symbols))
+		for _, s := range symbols {
+			sub.symbols[strings.ToUpper(s)] = true
+		}
+	}
+	sub.done = make(chan struct{})
+	sub.sending = new(sync.Mutex)
+	w.mu.Lock()
+	id := w.nextSub
+	w.nextSub++
+	w.subs[id] = sub
+	w.mu.Unlock()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			w.mu.Lock()
+			delete(w.subs, id)
+			w.mu.Unlock()
+			close(sub.done)
+			if sub.ch != nil {
+				// a send in progress returns now that done is closed
+				sub.sending.Lock()
+				close(sub.ch)
+				sub.sending.Unlock()
+			}
+		})
+	}
+}
+
+// Subscribe returns a channel that receives the events of symbols, or of every watched symbol if none are given,
+// along with a function that cancels the subscription and closes the channel. The channel is not closed when Run
+// returns, so receivers ranging over it must cancel the subscription once polling stops. Events are delivered
+// in order; a full channel delays polling until there is room or the subscription is cancelled, so slow receivers
+// should use a large buffer.
+func (w *Watcher) Subscribe(buffer int, symbols ...string) (<-chan QuoteEvent, func()) {
+	ch := make(chan QuoteEvent, buffer)
+	return ch, w.subscribe(watchSubscriber{ch: ch}, symbols)
+}
+
+// OnChange registers fn to be called with the events of symbols, or of every watched symbol if none are given.
+// fn is called synchronously from the polling goroutine. The returned function cancels the registration.
+func (w *Watcher) OnChange(fn func(QuoteEvent), symbols ...string) func() {
+	return w.subscribe(watchSubscriber{fn: fn}, symbols)
+}
+
+// OnQuote registers fn to be called with every Quote polled for symbols, or for every watched symbol if none are given,
+// whether or not it changed. Unlike OnChange, fn also receives each symbol's first Quote. fn is called synchronously
+// from the polling goroutine. The returned function cancels the registration.
+func (w *Watcher) OnQuote(fn func(q Quote, t time.Time), symbols ...string) func() {
+	return w.subscribe(watchSubscriber{quoteFn: fn}, symbols)
+}
+
+// Poll requests the Quotes of every watched symbol once and notifies subscribers of any changes.
+// It reports whether any of the symbols' markets are trading.
+func (w *Watcher) Poll(ctx context.Context) (bool, error) {
+	w.mu.Lock()
+	symbols := make([]string, 0, len(w.symbols))
+	for s := range w.symbols {
+		symbols = append(symbols, s)
+	}
+	w.mu.Unlock()
+	if len(symbols) == 0 {
+		return false, nil
+	}
+
+	quotes, err := w.Client.GetQuotes(symbols)
+	if err != nil {
+		return false, err
+	}
+	now := time.Now()
+	trading := false
+	var events []QuoteEvent
+	var polled []Quote
+	w.mu.Lock()
+	for sym, q := range quotes {
+		key := strings.ToUpper(sym)
+		if !w.symbols[key] {
+			continue
+		}
+		polled = append(polled, q)
+		if q.MarketState.IsTrading() {
+			trading = true
+		}
+		prev, seen := w.last[key]
+		w.last[key] = q
+		if !seen {
+			continue
+		}
+		if ch := diffQuotes(prev, q); ch != 0 {
+			events = append(events, QuoteEvent{Symbol: sym, Previous: prev, Current: q, Changes: ch, Time: now})
+		}
+	}
+	subs := make([]watchSubscriber, 0, len(w.subs))
+	for _, s := range w.subs {
+		subs = append(subs, s)
+	}
+	w.mu.Unlock()
+
+	for _, q := range polled {
+		for _, s := range subs {
+			if s.quoteFn != nil && s.wants(q.Symbol) && !s.cancelled() {
+				s.quoteFn(q, now)
+			}
+		}
+	}
+	for _, ev := range events {
+		for _, s := range subs {
+			// a subscription may have been cancelled since subs was copied
+			if s.quoteFn != nil || !s.wants(ev.Symbol) || s.cancelled() {
+				continue
+			}
+			if s.fn != nil {
+				s.fn(ev)
+				continue
+			}
+			if err := s.send(ctx, ev); err != nil {
+				return trading, err
+			}
+		}
+	}
+	return trading, nil
+}
+
+// minWatchInterval is the shortest interval between polls; shorter intervals are raised to it.
+const minWatchInterval = time.Second
+
+// nextWait returns how long Run waits before the next poll. After failures consecutive failed polls, the
+// interval doubles with each failure up to the larger of Interval and ClosedInterval.
+func (w *Watcher) nextWait(trading bool, failures int) time.Duration {
+	wait := w.Interval
+	if wait < minWatchInterval {
+		wait = minWatchInterval
+	}
+	limit := w.ClosedInterval
+	if limit < wait {
+		limit = wait
+	}
+	if failures > 0 {
+		for i := 0; i < failures && wait < limit; i++ {
+			wait *= 2
+		}
+		if wait > limit {
+			wait = limit
+		}
+		return wait
+	}
+	if !trading {
+		wait = limit
+	}
+	return wait
+}
+
+// Run polls until ctx is done and returns ctx.Err(). Intervals shorter than a second are treated as a second,
+// and polling backs off after errors.
+func (w *Watcher) Run(ctx context.Context) error {
+	failures := 0
+	for {
+		trading, err := w.Poll(ctx)
+		if err != nil && ctx.Err() == nil {
+			failures++
+			if w.OnError != nil {
+				w.OnError(err)
+			}
+			if w.Client.Verbose {
+				log.Println("watcher:", err)
+			}
+		} else {
+			failures = 0
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(w.nextWait(trading, failures)):
+		}
+	}
+}
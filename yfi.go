@@ -8,11 +8,17 @@
 //
 //  1. Ticker contains historical data in a simple and straightforward manner.
 //  2. Quote contains current market data about an asset.
-//  3. QuoteSummary contains extensive data about an asset based on the selected QueryParam. Because of how varied the data can be, the response is returned as a map[string]any. The plan is eventually to provide individual structs for each response type.
+//  3. QuoteSummary contains extensive data about an asset based on the selected QueryParam. Because of how varied the data can be, the response is returned as a map[string]any. Typed structs are provided for some modules and can be decoded with DecodeModule.
+//
+// Numeric values that Yahoo formats as {raw, fmt, longFmt} objects are represented by the Value type.
 package yfi
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
+	"io"
 	"net/http"
 	"time"
 )
@@ -25,16 +31,38 @@ const (
 	TIMEOUT = 5 * time.Second
 	// The default net/http user-agent is blocked for some Yahoo Finance endpoints
 	YFI_USER_AGENT = `Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:107.0) Gecko/20100101 Firefox/107.0`
+	// Provides long histories of financial statement line items
+	FUNDAMENTALS = `https://query2.finance.yahoo.com/ws/fundamentals-timeseries/v1/finance/timeseries/`
+	// Provides technical outlooks, valuations and research reports
+	INSIGHTS = `https://query2.finance.yahoo.com/ws/insights/v2/finance/insights`
+	// WebSocket endpoint that pushes real-time price updates
+	STREAMER = `wss://streamer.finance.yahoo.com/`
 )
 
 var (
-	ErrUnauthReq     = errors.New("request error 401")
-	ErrNotFound      = errors.New("request error 404")
-	ErrServer        = errors.New("request error 500")
-	ErrMalformedResp = errors.New("malformed response")
-	ErrInterval      = errors.New("invalid interval")
-	ErrRange         = errors.New("invalid time range")
-	ErrQuoteParam    = errors.New("invalid quote param")
+	ErrUnauthReq      = errors.New("request error 401")
+	ErrNotFound       = errors.New("request error 404")
+	ErrServer         = errors.New("request error 500")
+	ErrMalformedResp  = errors.New("malformed response")
+	ErrInterval       = errors.New("invalid interval")
+	ErrRange          = errors.New("invalid time range")
+	ErrQuoteParam     = errors.New("invalid quote param")
+	ErrMissingModule  = errors.New("module missing from response")
+	ErrPathNotFound   = errors.New("path not found")
+	ErrPathType       = errors.New("unexpected type at path")
+	ErrPathSyntax     = errors.New("invalid path syntax")
+	ErrFundamentals   = errors.New("invalid fundamentals type")
+	ErrImpliedVol     = errors.New("implied volatility not found")
+	ErrEmptySurface   = errors.New("volatility surface has no points")
+	ErrNoMatch        = errors.New("no matching symbol")
+	ErrIdentifier     = errors.New("invalid security identifier")
+	ErrAlertKind      = errors.New("invalid alert kind")
+	ErrNotStored      = errors.New("history not stored")
+	ErrColumnLength   = errors.New("history columns have different lengths")
+	ErrMalformedData  = errors.New("malformed data")
+	ErrColumn         = errors.New("invalid column")
+	ErrParquet        = errors.New("unsupported or malformed parquet file")
+	ErrStreamerClosed = errors.New("streamer closed")
 )
 
 type Client struct {
@@ -44,15 +72,68 @@ type Client struct {
 	HardTimeOut bool
 	Verbose     bool
 	UserAgent   string
+	// MaxConcurrency limits the number of requests that batch methods
+	// may have in flight at once. Values less than 1 are treated as 1.
+	MaxConcurrency int
+	// Cache, if not nil, caches Quote and QuoteSummary responses and is shared by copies of the Client.
+	Cache *Cache
 }
 
 func NewClient() Client {
 	return Client{
-		TimeOut:     5 * time.Second,
-		HttpClient:  *http.DefaultClient,
-		WaitPeriod:  250 * time.Millisecond,
-		HardTimeOut: false,
-		Verbose:     true,
-		UserAgent:   YFI_USER_AGENT,
+		TimeOut:        5 * time.Second,
+		HttpClient:     *http.DefaultClient,
+		WaitPeriod:     250 * time.Millisecond,
+		HardTimeOut:    false,
+		Verbose:        true,
+		UserAgent:      YFI_USER_AGENT,
+		MaxConcurrency: 4,
+	}
+}
+
+// getJSON sends a GET request to url and decodes the JSON response into v.
+func (c *Client) getJSON(url string, v any) error {
+	return c.doJSON(http.MethodGet, url, nil, v)
+}
+
+// doJSON sends a request to url and decodes the JSON response into v.
+// If body is not nil, it is encoded as JSON and sent as the request body.
+func (c *Client) doJSON(method, url string, body any, v any) error {
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(b)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.TimeOut)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, method, url, r)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.UserAgent != "" {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
+	resp, err := c.HttpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		return ErrUnauthReq
+	case resp.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case resp.StatusCode == http.StatusInternalServerError:
+		return ErrServer
+	case resp.StatusCode != http.StatusOK:
+		return errors.New("request error " + resp.Status)
 	}
+	jdec := json.NewDecoder(resp.Body)
+	return jdec.Decode(v)
 }
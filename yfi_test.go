@@ -1,8 +1,28 @@
 package yfi
 
 import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
 	"log"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestCurrency(t *testing.T) {
@@ -15,3 +35,1758 @@ func TestGetMarketsSummary(t *testing.T) {
 	markets, err := c.GetMarketsSummary()
 	log.Println(err, markets)
 }
+
+func TestGetTickerFirstBar(t *testing.T) {
+	c := newTestClient(func(req *http.Request) (*http.Response, error) {
+		body := "Date,Open,High,Low,Close,Adj Close,Volume\n" +
+			"2023-10-02,171.22,174.3,170.93,173.75,173.04,52164500\n" +
+			"2023-10-03,172.26,173.63,170.82,172.4,171.7,49594600\n"
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body))}, nil
+	})
+	start := time.Date(2023, 10, 2, 0, 0, 0, 0, time.UTC)
+	check := func(name string, tk Ticker) {
+		if tk.Err != nil || len(tk.HistoricDates) != 2 {
+			t.Fatalf("%s: unexpected ticker %+v", name, tk)
+		}
+		if tk.HistoricDates[0] != start.Unix() || tk.HistoricOpen[0] != 171.22 || tk.HistoricClose[0] != 173.75 ||
+			tk.HistoricAdjClose[0] != 173.04 || tk.HistoricVolume[0] != 52164500 {
+			t.Errorf("%s: first bar not populated: %+v", name, tk)
+		}
+	}
+	tk, err := c.GetTicker("AAPL", OneDay, start, start.AddDate(0, 0, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("GetTicker", tk)
+	check("GetTickersBurst", c.GetTickersBurst([]string{"AAPL"}, OneDay, start, start.AddDate(0, 0, 2))[0])
+}
+
+func TestDecodeModule(t *testing.T) {
+	var summary map[string]any
+	err := json.Unmarshal([]byte(`{
+		"calendarEvents": {
+			"maxAge": 1,
+			"earnings": {
+				"earningsDate": [{"raw": 1706216400, "fmt": "2024-01-25"}, {"raw": 1706648400, "fmt": "2024-01-30"}],
+				"earningsAverage": {"raw": 2.1, "fmt": "2.10"},
+				"revenueAverage": {"raw": 117910000000, "fmt": "117.91B", "longFmt": "117,910,000,000"}
+			},
+			"exDividendDate": {"raw": 1699574400, "fmt": "2023-11-10"},
+			"dividendDate": {"raw": 1699833600, "fmt": "2023-11-16"}
+		},
+		"secFilings": {
+			"filings": [{"date": "2023-11-03", "epochDate": 1698984000, "type": "10-K", "title": "Annual Report", "edgarUrl": "https://example.com/10-K"}]
+		},
+		"esgScores": {
+			"totalEsg": {"raw": 16.68, "fmt": "16.7"},
+			"highestControversy": 3,
+			"peerEsgScorePerformance": {"min": 6.2, "avg": 14.9, "max": 28.2}
+		}
+	}`), &summary)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var cal CalendarEventsModule
+	if err = DecodeModule(summary, CalendarEvents, &cal); err != nil {
+		t.Fatal(err)
+	}
+	if dates := cal.Earnings.EarningsDates(); len(dates) != 2 || dates[0].Unix() != 1706216400 {
+		t.Errorf("unexpected earnings dates %v", dates)
+	}
+	if cal.Earnings.RevenueAverage.Raw != 117910000000 {
+		t.Errorf("unexpected revenue average %v", cal.Earnings.RevenueAverage)
+	}
+
+	var filings SecFilingsModule
+	if err = DecodeModule(summary, SecFilings, &filings); err != nil {
+		t.Fatal(err)
+	}
+	if len(filings.Filings) != 1 || filings.Filings[0].Type != "10-K" {
+		t.Errorf("unexpected filings %v", filings)
+	}
+
+	var esg EsgScoresModule
+	if err = DecodeModule(summary, EsgScores, &esg); err != nil {
+		t.Fatal(err)
+	}
+	if esg.TotalEsg.Raw != 16.68 || esg.PeerEsgScorePerformance.Max.Raw != 28.2 || esg.HighestControversy.Raw != 3 {
+		t.Errorf("unexpected esg scores %v", esg)
+	}
+
+	if err = DecodeModule(summary, Price, &esg); err != ErrMissingModule {
+		t.Errorf("expected ErrMissingModule, got %v", err)
+	}
+}
+
+func TestValue(t *testing.T) {
+	var v struct {
+		Obj   Value `json:"obj"`
+		Bare  Value `json:"bare"`
+		Empty Value `json:"empty"`
+		Null  Value `json:"null"`
+		Unset Value `json:"unset"`
+	}
+	err := json.Unmarshal([]byte(`{"obj": {"raw": 1.5, "fmt": "1.50", "longFmt": "1.500"}, "bare": 2, "empty": {}, "null": null}`), &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !v.Obj.Valid || v.Obj.Raw != 1.5 || v.Obj.Fmt != "1.50" || v.Obj.LongFmt != "1.500" {
+		t.Errorf("unexpected object value %#v", v.Obj)
+	}
+	if !v.Bare.Valid || v.Bare.Raw != 2 {
+		t.Errorf("unexpected bare value %#v", v.Bare)
+	}
+	if v.Empty.Valid || v.Null.Valid || v.Unset.Valid {
+		t.Errorf("expected empty, null and unset values to be invalid")
+	}
+
+	b, err := json.Marshal(v.Obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rt Value
+	if err = json.Unmarshal(b, &rt); err != nil || rt != v.Obj {
+		t.Errorf("round trip failed: %s %#v %v", b, rt, err)
+	}
+}
+
+func TestQuoteSummaryPath(t *testing.T) {
+	var summary QuoteSummary
+	err := json.Unmarshal([]byte(`{
+		"financialData": {"currentPrice": {"raw": 189.5, "fmt": "189.50"}, "targetLowPrice": {}, "financialCurrency": "USD"},
+		"secFilings": {"filings": [{"type": "10-K", "epochDate": 1698984000}, {"type": "8-K"}]}
+	}`), &summary)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if f, err := summary.Float("financialData.currentPrice.raw"); err != nil || f != 189.5 {
+		t.Errorf("Float(raw) = %v, %v", f, err)
+	}
+	if f, err := summary.Float("financialData.currentPrice"); err != nil || f != 189.5 {
+		t.Errorf("Float(object) = %v, %v", f, err)
+	}
+	if v, err := summary.Value("financialData.targetLowPrice"); err != nil || v.Valid {
+		t.Errorf("Value(empty) = %v, %v", v, err)
+	}
+	if s, err := summary.String("secFilings.filings[1].type"); err != nil || s != "8-K" {
+		t.Errorf("String(index) = %v, %v", s, err)
+	}
+	if tm, err := summary.Time("secFilings.filings.0.epochDate"); err != nil || tm.Unix() != 1698984000 {
+		t.Errorf("Time = %v, %v", tm, err)
+	}
+	if l, err := summary.List("secFilings.filings"); err != nil || len(l) != 2 {
+		t.Errorf("List = %v, %v", l, err)
+	}
+
+	var perr *PathError
+	_, err = summary.Float("financialData.missing.raw")
+	if !errors.As(err, &perr) || perr.Segment != "missing" || !errors.Is(err, ErrPathNotFound) {
+		t.Errorf("expected not found error for segment missing, got %v", err)
+	}
+	_, err = summary.Float("financialData.financialCurrency")
+	if !errors.Is(err, ErrPathType) {
+		t.Errorf("expected type error, got %v", err)
+	}
+	_, err = summary.Get("secFilings.filings[2]")
+	if !errors.As(err, &perr) || perr.Segment != "2" {
+		t.Errorf("expected out of range error, got %v", err)
+	}
+	_, err = summary.Get("secFilings..filings")
+	if !errors.Is(err, ErrPathSyntax) {
+		t.Errorf("expected syntax error, got %v", err)
+	}
+}
+
+// roundTripFunc allows tests to serve canned responses without network access.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func jsonResponse(status int, body string) *http.Response {
+	return &http.Response{
+		StatusCode: status,
+		Status:     strconv.Itoa(status) + " " + http.StatusText(status),
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}
+}
+
+func newTestClient(f roundTripFunc) Client {
+	c := NewClient()
+	c.HttpClient = http.Client{Transport: f}
+	c.WaitPeriod = 0
+	c.Verbose = false
+	return c
+}
+
+func TestGetQuoteSummaries(t *testing.T) {
+	var inFlight, maxInFlight int32
+	c := newTestClient(func(req *http.Request) (*http.Response, error) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			m := atomic.LoadInt32(&maxInFlight)
+			if n <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		symbol := strings.TrimPrefix(req.URL.Path, "/v10/finance/quoteSummary/")
+		if symbol == "BAD" {
+			return jsonResponse(http.StatusNotFound, `{}`), nil
+		}
+		return jsonResponse(http.StatusOK, `{"quoteSummary": {"result": [{"price": {"symbol": "`+symbol+`"}}], "error": null}}`), nil
+	})
+	c.MaxConcurrency = 2
+
+	symbols := []string{"AAPL", "MSFT", "BAD", "GOOG", "AMZN"}
+	res := c.GetQuoteSummaries(symbols, []QuoteParam{Price})
+	if len(res) != len(symbols) {
+		t.Fatalf("expected %d results, got %d", len(symbols), len(res))
+	}
+	for i, r := range res {
+		if r.Symbol != symbols[i] {
+			t.Errorf("result %d has symbol %s, expected %s", i, r.Symbol, symbols[i])
+		}
+		if r.Symbol == "BAD" {
+			if r.Err != ErrNotFound {
+				t.Errorf("expected ErrNotFound for BAD, got %v", r.Err)
+			}
+			continue
+		}
+		if s, err := r.Summary.String("price.symbol"); err != nil || s != r.Symbol {
+			t.Errorf("unexpected summary for %s: %v %v", r.Symbol, s, err)
+		}
+	}
+	if maxInFlight > 2 {
+		t.Errorf("expected at most 2 concurrent requests, got %d", maxInFlight)
+	}
+}
+
+func TestGetQuoteSummaryReport(t *testing.T) {
+	var modules string
+	c := newTestClient(func(req *http.Request) (*http.Response, error) {
+		modules = req.URL.Query().Get("modules")
+		return jsonResponse(http.StatusOK, `{"quoteSummary": {"result": [{
+			"price": {"maxAge": 1, "symbol": "AAPL"},
+			"esgScores": {"maxAge": 86400}
+		}], "error": null}}`), nil
+	})
+
+	RegisterQuoteParam("futureModule")
+	t.Cleanup(func() { unregisterQuoteParam("futureModule") })
+	params := []QuoteParam{Price, EsgScores, InsiderTransactions, "notAModule", "futureModule"}
+	_, report, err := c.GetQuoteSummaryReport("AAPL", params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if modules != "price,esgScores,insiderTransactions,futureModule" {
+		t.Errorf("unexpected modules requested: %s", modules)
+	}
+	if len(report.Invalid) != 1 || report.Invalid[0] != "notAModule" {
+		t.Errorf("unexpected invalid modules %v", report.Invalid)
+	}
+	if len(report.Empty) != 1 || report.Empty[0] != EsgScores {
+		t.Errorf("unexpected empty modules %v", report.Empty)
+	}
+	if len(report.Missing) != 2 || report.Missing[0] != InsiderTransactions || report.Missing[1] != "futureModule" {
+		t.Errorf("unexpected missing modules %v", report.Missing)
+	}
+	if report.Complete() {
+		t.Error("expected incomplete report")
+	}
+
+	if _, _, err = c.GetQuoteSummaryReport("AAPL", []QuoteParam{"notAModule"}); err != ErrQuoteParam {
+		t.Errorf("expected ErrQuoteParam, got %v", err)
+	}
+}
+
+func TestGetFundamentalsTimeseries(t *testing.T) {
+	var types string
+	c := newTestClient(func(req *http.Request) (*http.Response, error) {
+		types = req.URL.Query().Get("type")
+		return jsonResponse(http.StatusOK, `{"timeseries": {"result": [
+			{"meta": {"symbol": ["AAPL"], "type": ["annualTotalRevenue"]}, "timestamp": [1632960000, 1601424000],
+			 "annualTotalRevenue": [
+				{"asOfDate": "2021-09-30", "periodType": "12M", "currencyCode": "USD", "reportedValue": {"raw": 365817000000, "fmt": "365.82B"}},
+				null,
+				{"asOfDate": "2020-09-30", "periodType": "12M", "currencyCode": "USD", "reportedValue": {"raw": 274515000000, "fmt": "274.52B"}}
+			 ]},
+			{"meta": {"symbol": ["AAPL"], "type": ["quarterlyTotalRevenue"]}}
+		], "error": null}}`), nil
+	})
+
+	start := time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	f, err := c.GetFundamentalsTimeseries("AAPL", []FundamentalsFreq{Annual, Quarterly}, []FundamentalsItem{TotalRevenue}, start, end)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if types != "annualTotalRevenue,quarterlyTotalRevenue" {
+		t.Errorf("unexpected types requested: %s", types)
+	}
+	s := f.Get(Annual, TotalRevenue)
+	if len(s.Points) != 2 || s.Points[0].AsOfDate.Year() != 2020 || s.Points[1].ReportedValue.Raw != 365817000000 {
+		t.Errorf("unexpected series %+v", s)
+	}
+	if s := f.Get(Quarterly, TotalRevenue); len(s.Points) != 0 || s.Item != TotalRevenue {
+		t.Errorf("expected empty quarterly series, got %+v", s)
+	}
+
+	_, err = c.GetFundamentalsTimeseries("AAPL", []FundamentalsFreq{Trailing}, []FundamentalsItem{TotalAssets}, start, end)
+	if err != ErrFundamentals {
+		t.Errorf("expected ErrFundamentals for trailing balance sheet item, got %v", err)
+	}
+}
+
+func TestGetOptionChains(t *testing.T) {
+	chain := func(exp string) string {
+		return `{"optionChain": {"result": [{
+			"underlyingSymbol": "AAPL", "expirationDates": [1700179200, 1700784000], "strikes": [180, 190],
+			"quote": {"symbol": "AAPL", "regularMarketPrice": 185.5},
+			"options": [{"expirationDate": ` + exp + `,
+				"calls": [{"contractSymbol": "AAPL231117C00180000", "strike": 180, "lastPrice": 6.1, "bid": 6, "ask": 6.2,
+					"volume": 120, "openInterest": 3400, "expiration": ` + exp + `, "lastTradeDate": 1700150000,
+					"impliedVolatility": 0.25, "inTheMoney": true}],
+				"puts": [{"contractSymbol": "AAPL231117P00190000", "strike": 190, "inTheMoney": true}]}]
+		}], "error": null}}`
+	}
+	var dates []string
+	c := newTestClient(func(req *http.Request) (*http.Response, error) {
+		date := req.URL.Query().Get("date")
+		dates = append(dates, date)
+		if date == "" {
+			date = "1700179200"
+		}
+		return jsonResponse(http.StatusOK, chain(date)), nil
+	})
+
+	chains, err := c.GetOptionChains("AAPL")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dates) != 2 || dates[0] != "" || dates[1] != "1700784000" {
+		t.Errorf("unexpected requested dates %v", dates)
+	}
+	if len(chains) != 2 || chains[1].ExpirationDate != 1700784000 {
+		t.Fatalf("unexpected chains %+v", chains)
+	}
+	call := chains[0].Calls[0]
+	if call.Strike != 180 || call.OpenInterest != 3400 || !call.InTheMoney || call.LastTradeTime().Unix() != 1700150000 {
+		t.Errorf("unexpected call %+v", call)
+	}
+	if chains[0].Quote.RegularMarketPrice != 185.5 || len(chains[0].Strikes) != 2 {
+		t.Errorf("unexpected chain %+v", chains[0])
+	}
+}
+
+func TestOptionPricing(t *testing.T) {
+	near := func(a, b, tol float64) bool { return math.Abs(a-b) < tol }
+	p := OptionParams{Type: Call, Underlying: 100, Strike: 100, Expiry: 1, Rate: 0.05, Volatility: 0.2}
+
+	if c := BlackScholes.Price(p); !near(c, 10.4506, 1e-4) {
+		t.Errorf("Black-Scholes call = %v", c)
+	}
+	g := BlackScholes.Greeks(p)
+	if !near(g.Delta, 0.6368, 1e-4) || !near(g.Gamma, 0.018762, 1e-5) || !near(g.Vega, 37.524, 1e-3) {
+		t.Errorf("unexpected call greeks %+v", g)
+	}
+	p.Type = Put
+	if v := BlackScholes.Price(p); !near(v, 5.5735, 1e-4) {
+		t.Errorf("Black-Scholes put = %v", v)
+	}
+	if c := Black76.Price(OptionParams{Type: Call, Underlying: 100, Strike: 100, Expiry: 1, Rate: 0.05, Volatility: 0.2}); !near(c, 7.5771, 1e-4) {
+		t.Errorf("Black-76 call = %v", c)
+	}
+
+	// theta and rho are checked against finite differences
+	for _, m := range []Model{BlackScholes, Black76} {
+		for _, typ := range []OptionType{Call, Put} {
+			q := OptionParams{Type: typ, Underlying: 105, Strike: 100, Expiry: 0.5, Rate: 0.03, DividendYield: 0.01, Volatility: 0.25}
+			g := m.Greeks(q)
+			h := 1e-5
+			up, dn := q, q
+			up.Expiry, dn.Expiry = q.Expiry-h, q.Expiry+h
+			if theta := (m.Price(up) - m.Price(dn)) / (2 * h); !near(g.Theta, theta, 1e-4) {
+				t.Errorf("model %d %v theta = %v, expected %v", m, typ, g.Theta, theta)
+			}
+			up, dn = q, q
+			up.Rate, dn.Rate = q.Rate+h, q.Rate-h
+			if rho := (m.Price(up) - m.Price(dn)) / (2 * h); !near(g.Rho, rho, 1e-4) {
+				t.Errorf("model %d %v rho = %v, expected %v", m, typ, g.Rho, rho)
+			}
+
+			vol, err := m.ImpliedVolatility(q, m.Price(q))
+			if err != nil || !near(vol, q.Volatility, 1e-6) {
+				t.Errorf("model %d %v implied volatility = %v, %v", m, typ, vol, err)
+			}
+		}
+	}
+
+	p.Strike = 120
+	if _, err := BlackScholes.ImpliedVolatility(p, 10); err != ErrImpliedVol {
+		t.Errorf("expected ErrImpliedVol for price below intrinsic value, got %v", err)
+	}
+
+	now := time.Unix(1700179200, 0).AddDate(0, 0, -73)
+	call := OptionContract{ContractSymbol: "AAPL231117C00180000", Strike: 180, Bid: 8, Ask: 8.4, Expiration: 1700179200, ImpliedVolatility: 0.22}
+	put := OptionContract{ContractSymbol: "AAPL231117P00180000", Strike: 180, Expiration: 1700179200, ImpliedVolatility: 0.22}
+	if call.Type() != Call || put.Type() != Put {
+		t.Errorf("unexpected contract types %v %v", call.Type(), put.Type())
+	}
+	if _, err := call.SolveImpliedVolatility(BlackScholes, 185, 0.05, 0, now); err != nil {
+		t.Error(err)
+	}
+	exp := PortfolioExposure(BlackScholes, []OptionPosition{{Contract: call, Quantity: 1}, {Contract: put, Quantity: 1}}, 185, 0.05, 0, now)
+	callDelta := BlackScholes.Greeks(call.Params(185, 0.05, 0, now)).Delta
+	if !near(exp.Delta, 100*(2*callDelta-1), 1e-9) {
+		t.Errorf("unexpected straddle delta %v", exp.Delta)
+	}
+}
+
+func TestVolSurface(t *testing.T) {
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	exp1 := now.AddDate(0, 0, 73).Unix()
+	exp2 := now.AddDate(0, 0, 365).Unix()
+	contract := func(typ string, exp int64, strike, iv float64, itm bool) OptionContract {
+		return OptionContract{ContractSymbol: "XYZ230101" + typ + "00000000", Strike: strike, Expiration: exp, ImpliedVolatility: iv, InTheMoney: itm}
+	}
+	chains := []OptionChain{
+		{
+			UnderlyingSymbol: "XYZ", Quote: Quote{RegularMarketPrice: 100}, ExpirationDate: exp1,
+			Calls: []OptionContract{contract("C", exp1, 90, 0.5, true), contract("C", exp1, 100, 0.30, false), contract("C", exp1, 110, 0.25, false)},
+			Puts:  []OptionContract{contract("P", exp1, 90, 0.35, false), contract("P", exp1, 100, 0.32, false), contract("P", exp1, 110, 0.5, true)},
+		},
+		{
+			UnderlyingSymbol: "XYZ", Quote: Quote{RegularMarketPrice: 100}, ExpirationDate: exp2,
+			Calls: []OptionContract{contract("C", exp2, 100, 0.20, false), contract("C", exp2, 120, 0.18, false)},
+			Puts:  []OptionContract{contract("P", exp2, 80, 0.26, false), contract("P", exp2, 100, 0.20, false)},
+		},
+	}
+	s, err := BuildVolSurface(chains, SurfaceOptions{UseYahooIV: true}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Points) != 8 {
+		t.Fatalf("expected 8 out-of-the-money points, got %d", len(s.Points))
+	}
+
+	near := func(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+	ts := s.TermStructure()
+	if len(ts) != 2 || !near(ts[0].ATMVol, 0.31) || !near(ts[1].ATMVol, 0.20) {
+		t.Errorf("unexpected term structure %+v", ts)
+	}
+	smiles := s.Smiles()
+	if !near(smiles[0].Skew, 0.10) {
+		t.Errorf("unexpected skew %v", smiles[0].Skew)
+	}
+	if v, _ := s.Vol(smiles[0].Expiry, 105); !near(v, 0.28) {
+		t.Errorf("unexpected strike interpolation %v", v)
+	}
+	// halfway in time between expirations, total variance is interpolated linearly
+	mid := (smiles[0].Expiry + smiles[1].Expiry) / 2
+	want := math.Sqrt((0.31*0.31*smiles[0].Expiry + 0.2*0.2*smiles[1].Expiry) / 2 / mid)
+	if v, _ := s.VolAtMoneyness(mid, 1); !near(v, want) {
+		t.Errorf("unexpected expiry interpolation %v, expected %v", v, want)
+	}
+
+	var buf strings.Builder
+	if err = s.WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(buf.String(), "\n"); lines != 9 {
+		t.Errorf("expected 9 CSV lines, got %d", lines)
+	}
+	buf.Reset()
+	if err = s.WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var rt VolSurface
+	if err = json.Unmarshal([]byte(buf.String()), &rt); err != nil || len(rt.Points) != 8 || rt.Points[0].Type != Put {
+		t.Errorf("JSON round trip failed: %v %+v", err, rt.Points)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	var queries []string
+	c := newTestClient(func(req *http.Request) (*http.Response, error) {
+		queries = append(queries, req.URL.Query().Get("q"))
+		return jsonResponse(http.StatusOK, `{"count": 3, "quotes": [
+			{"exchange": "GER", "shortname": "APPLE INC", "quoteType": "EQUITY", "symbol": "APC.DE", "score": 20000, "isYahooFinance": true},
+			{"exchange": "NMS", "shortname": "Apple Inc.", "quoteType": "EQUITY", "symbol": "AAPL", "score": 30000, "isYahooFinance": true},
+			{"index": "quotes", "score": 40000}
+		], "news": [{"uuid": "1", "title": "Apple news", "providerPublishTime": 1700000000, "relatedTickers": ["AAPL"]}]}`), nil
+	})
+
+	res, err := c.Search("apple", 3, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Quotes) != 3 || len(res.News) != 1 || res.News[0].RelatedTickers[0] != "AAPL" {
+		t.Errorf("unexpected search result %+v", res)
+	}
+	q, err := c.ResolveSymbol("apple")
+	if err != nil || q.Symbol != "AAPL" {
+		t.Errorf("ResolveSymbol = %v, %v", q.Symbol, err)
+	}
+	q, err = c.ResolveSymbol("apc.de")
+	if err != nil || q.Symbol != "APC.DE" {
+		t.Errorf("ResolveSymbol exact = %v, %v", q.Symbol, err)
+	}
+
+	if !IsISIN("US0378331005") || IsISIN("US0378331006") || !IsISIN("DE0007164600") {
+		t.Error("unexpected ISIN validation")
+	}
+	if !IsCUSIP("037833100") || IsCUSIP("037833101") {
+		t.Error("unexpected CUSIP validation")
+	}
+	if _, err = c.ResolveCUSIP("037833100"); err != nil {
+		t.Fatal(err)
+	}
+	if queries[len(queries)-1] != "US0378331005" {
+		t.Errorf("expected CUSIP to be converted to ISIN, searched %s", queries[len(queries)-1])
+	}
+	if _, err = c.ResolveISIN("US0378331006"); err != ErrIdentifier {
+		t.Errorf("expected ErrIdentifier, got %v", err)
+	}
+}
+
+func TestScreener(t *testing.T) {
+	var bodies []map[string]any
+	c := newTestClient(func(req *http.Request) (*http.Response, error) {
+		if req.Method != http.MethodPost {
+			if req.URL.Query().Get("scrIds") != "day_gainers" {
+				t.Errorf("unexpected predefined request %s", req.URL)
+			}
+			return jsonResponse(http.StatusOK, `{"finance": {"result": [{"id": "day_gainers", "total": 1, "quotes": [{"symbol": "XYZ"}]}]}}`), nil
+		}
+		var body map[string]any
+		json.NewDecoder(req.Body).Decode(&body)
+		bodies = append(bodies, body)
+		offset := int(body["offset"].(float64))
+		quotes := []string{}
+		for i := offset; i < offset+2 && i < 5; i++ {
+			quotes = append(quotes, `{"symbol": "S`+strconv.Itoa(i)+`"}`)
+		}
+		return jsonResponse(http.StatusOK, `{"finance": {"result": [{"start": `+strconv.Itoa(offset)+`, "total": 5, "quotes": [`+strings.Join(quotes, ",")+`]}]}}`), nil
+	})
+
+	s := NewScreener(And(IsIn("region", "us", "ca"), Btwn("peratio.lasttwelvemonths", 0, 20))).SortBy("percentchange", true).PageSize(2)
+	it := c.ScreenerIterator(s)
+	var symbols []string
+	for it.Next() {
+		symbols = append(symbols, it.Quote().Symbol)
+	}
+	if it.Err() != nil {
+		t.Fatal(it.Err())
+	}
+	if strings.Join(symbols, ",") != "S0,S1,S2,S3,S4" || len(bodies) != 3 {
+		t.Errorf("unexpected iteration %v over %d pages", symbols, len(bodies))
+	}
+	b, _ := json.Marshal(bodies[0]["query"])
+	want := `{"operands":[{"operands":[{"operands":["region","us"],"operator":"eq"},{"operands":["region","ca"],"operator":"eq"}],"operator":"or"},{"operands":["peratio.lasttwelvemonths",0,20],"operator":"btwn"}],"operator":"and"}`
+	if string(b) != want {
+		t.Errorf("unexpected query %s", b)
+	}
+	if bodies[0]["sortType"] != "ASC" || bodies[0]["sortField"] != "percentchange" {
+		t.Errorf("unexpected sort %v %v", bodies[0]["sortField"], bodies[0]["sortType"])
+	}
+
+	it = c.ScreenerIterator(NewScreener(Eq("region", "us")).PageSize(2).Limit(3))
+	n := 0
+	for it.Next() {
+		n++
+	}
+	if n != 3 {
+		t.Errorf("expected limit of 3 rows, got %d", n)
+	}
+
+	page, err := c.Screen(NewPredefinedScreener(DayGainers), 0)
+	if err != nil || page.Id != "day_gainers" || page.Quotes[0].Symbol != "XYZ" {
+		t.Errorf("unexpected predefined page %+v, %v", page, err)
+	}
+}
+
+func TestTrendingAndRecommended(t *testing.T) {
+	c := newTestClient(func(req *http.Request) (*http.Response, error) {
+		switch {
+		case strings.HasPrefix(req.URL.Path, "/v1/finance/trending/US"):
+			return jsonResponse(http.StatusOK, `{"finance": {"result": [{"count": 2, "quotes": [{"symbol": "NVDA"}, {"symbol": "NOPE"}]}], "error": null}}`), nil
+		case strings.HasPrefix(req.URL.Path, "/v6/finance/recommendationsbysymbol/AAPL"):
+			return jsonResponse(http.StatusOK, `{"finance": {"result": [{"symbol": "AAPL", "recommendedSymbols": [{"symbol": "MSFT", "score": 0.28}]}], "error": null}}`), nil
+		case strings.HasPrefix(req.URL.Path, "/v6/finance/quote"):
+			return jsonResponse(http.StatusOK, `{"quoteResponse": {"result": [{"symbol": "NVDA", "regularMarketPrice": 450}], "error": null}}`), nil
+		}
+		t.Errorf("unexpected request %s", req.URL)
+		return jsonResponse(http.StatusNotFound, `{}`), nil
+	})
+
+	trending, err := c.GetTrending("US", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = c.HydrateQuotes(trending); err != nil {
+		t.Fatal(err)
+	}
+	if len(trending) != 2 || trending[0].Quote == nil || trending[0].Quote.RegularMarketPrice != 450 || trending[1].Quote != nil {
+		t.Errorf("unexpected trending symbols %+v", trending)
+	}
+
+	rec, err := c.GetRecommendedSymbols("AAPL")
+	if err != nil || len(rec) != 1 || rec[0].Symbol != "MSFT" || rec[0].Score != 0.28 {
+		t.Errorf("unexpected recommendations %+v, %v", rec, err)
+	}
+}
+
+func TestGetInsights(t *testing.T) {
+	c := newTestClient(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Query().Get("symbol") != "AAPL" {
+			t.Errorf("unexpected request %s", req.URL)
+		}
+		return jsonResponse(http.StatusOK, `{"finance": {"result": {"symbol": "AAPL",
+			"instrumentInfo": {
+				"technicalEvents": {"provider": "Trading Central", "shortTermOutlook": {"direction": "Bearish", "score": 2, "scoreDescription": "Weak Bearish Evidence"}},
+				"keyTechnicals": {"support": 165.67, "resistance": 198.23, "stopLoss": 160.1},
+				"valuation": {"description": "Overvalued", "discount": "-8%"}
+			},
+			"recommendation": {"targetPrice": 210, "rating": "BUY"},
+			"reports": [{"id": "1", "reportTitle": "Apple: raising target", "targetPrice": 210}],
+			"sigDevs": [{"headline": "Apple announces buyback", "date": "2023-05-04"}]
+		}, "error": null}}`), nil
+	})
+	in, err := c.GetInsights("AAPL")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if in.InstrumentInfo.TechnicalEvents.ShortTermOutlook.Direction != "Bearish" || in.InstrumentInfo.KeyTechnicals.Support != 165.67 ||
+		in.Recommendation.Rating != "BUY" || len(in.Reports) != 1 || len(in.SigDevs) != 1 {
+		t.Errorf("unexpected insights %+v", in)
+	}
+}
+
+func TestMarketTime(t *testing.T) {
+	c := newTestClient(func(req *http.Request) (*http.Response, error) {
+		return jsonResponse(http.StatusOK, `{"finance": {"marketTimes": [{"id": "us", "name": "U.S.", "marketTime": [{
+			"id": "us", "name": "U.S. markets", "status": "open", "message": "U.S. markets close in 3 hrs",
+			"open": "2023-11-17T14:30:00Z", "close": "2023-11-17T21:00:00Z", "time": "2023-11-17T12:58:24-05:00",
+			"timezone": [{"dst": "false", "gmtoffset": "-18000000", "short": "EST", "$text": "America/New_York"}]
+		}]}], "error": null}}`), nil
+	})
+	m, err := c.GetMarketTime("US")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := m.Time // Friday afternoon
+	if !m.IsOpen(now) {
+		t.Error("expected market to be open")
+	}
+	if next := m.NextClose(now); !next.Equal(m.Close) {
+		t.Errorf("unexpected next close %v", next)
+	}
+	// the next open after Friday's session is on Monday
+	if next := m.NextOpen(now); next.Weekday() != time.Monday || !next.Equal(m.Open.AddDate(0, 0, 3)) {
+		t.Errorf("unexpected next open %v", next)
+	}
+	if next := m.NextOpen(m.Open.Add(-time.Hour)); !next.Equal(m.Open) {
+		t.Errorf("unexpected next open before session %v", next)
+	}
+
+	var q Quote
+	if err = json.Unmarshal([]byte(`{"marketState": "POST"}`), &q); err != nil {
+		t.Fatal(err)
+	}
+	if q.MarketState != MarketPost || q.MarketState.IsOpen() || !q.MarketState.IsTrading() {
+		t.Errorf("unexpected market state %v", q.MarketState)
+	}
+}
+
+func TestCalendar(t *testing.T) {
+	c := newTestClient(func(req *http.Request) (*http.Response, error) {
+		if req.Method == http.MethodPost {
+			return jsonResponse(http.StatusOK, `{"finance": {"result": [{"documents": [{
+				"columns": [{"id": "ticker"}, {"id": "companyshortname"}, {"id": "startdatetime"}, {"id": "startdatetimetype"}, {"id": "epsestimate"}, {"id": "epsactual"}, {"id": "epssurprisepct"}],
+				"rows": [["MSFT", "Microsoft Corp", "2023-10-24T20:00:00.000Z", "AMC", 2.65, null, null], ["AAPL", "Apple Inc", "2023-10-23T20:30:00.000Z", "AMC", 1.39, 1.46, 5.26]]
+			}]}], "error": null}}`), nil
+		}
+		switch strings.TrimPrefix(req.URL.Path, "/v10/finance/quoteSummary/") {
+		case "AAPL":
+			return jsonResponse(http.StatusOK, `{"quoteSummary": {"result": [{
+				"price": {"shortName": "Apple Inc."},
+				"calendarEvents": {
+					"earnings": {"earningsDate": [{"raw": 1698364800}, {"raw": 1698796800}], "earningsAverage": {"raw": 1.39}},
+					"exDividendDate": {"raw": 1697155200}, "dividendDate": {"raw": 1690000000}
+				}
+			}]}}`), nil
+		case "KO":
+			return jsonResponse(http.StatusOK, `{"quoteSummary": {"result": [{"calendarEvents": {"earnings": {"earningsDate": []}, "dividendDate": {"raw": 1697500000}}}]}}`), nil
+		}
+		return jsonResponse(http.StatusNotFound, `{}`), nil
+	})
+
+	from := time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2023, 11, 1, 0, 0, 0, 0, time.UTC)
+	cal := c.GetCalendar([]string{"AAPL", "KO", "BAD"}, from, to)
+	if cal.Errors["BAD"] != ErrNotFound || len(cal.Errors) != 1 {
+		t.Errorf("unexpected errors %v", cal.Errors)
+	}
+	var kinds []string
+	for _, ev := range cal.Events {
+		kinds = append(kinds, ev.Symbol+":"+string(ev.Type))
+	}
+	if strings.Join(kinds, ",") != "AAPL:exDividend,KO:dividend,AAPL:earnings" {
+		t.Errorf("unexpected events %v", kinds)
+	}
+	if ev := cal.Events[2]; ev.Name != "Apple Inc." || ev.EndDate.Unix() != 1698796800 || ev.EarningsAverage.Raw != 1.39 {
+		t.Errorf("unexpected earnings event %+v", ev)
+	}
+
+	var buf strings.Builder
+	if err := cal.WriteICS(&buf); err != nil {
+		t.Fatal(err)
+	}
+	ics := buf.String()
+	if !strings.Contains(ics, "DTSTART;VALUE=DATE:20231027\r\nDTEND;VALUE=DATE:20231102\r\n") || !strings.Contains(ics, "SUMMARY:AAPL earnings (estimated)") {
+		t.Errorf("unexpected ics output:\n%s", ics)
+	}
+
+	earnings, err := c.GetEarningsCalendar("us", from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(earnings.Events) != 2 || earnings.Events[0].Symbol != "AAPL" || !earnings.Events[0].EarningsActual.Valid || earnings.Events[1].EarningsActual.Valid {
+		t.Errorf("unexpected earnings calendar %+v", earnings.Events)
+	}
+}
+
+// appendProtoTag and the helpers below encode PricingData messages for the streamer stand-in.
+func appendProtoTag(b []byte, field, wireType uint64) []byte {
+	return binary.AppendUvarint(b, field<<3|wireType)
+}
+
+func appendProtoString(b []byte, field uint64, s string) []byte {
+	b = appendProtoTag(b, field, 2)
+	b = binary.AppendUvarint(b, uint64(len(s)))
+	return append(b, s...)
+}
+
+func appendProtoFloat(b []byte, field uint64, f float32) []byte {
+	b = appendProtoTag(b, field, 5)
+	return binary.LittleEndian.AppendUint32(b, math.Float32bits(f))
+}
+
+func appendProtoSint(b []byte, field uint64, v int64) []byte {
+	b = appendProtoTag(b, field, 0)
+	return binary.AppendUvarint(b, uint64(v<<1)^uint64(v>>63))
+}
+
+// newStreamerStandIn starts a local WebSocket server. Each connection's subscribe message is sent on subs,
+// after which a tick for every subscribed symbol is sent and the connection is dropped.
+func newStreamerStandIn(t *testing.T, subs chan<- []string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Upgrade") != "websocket" {
+			http.Error(w, "expected websocket", http.StatusBadRequest)
+			return
+		}
+		conn, brw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		brw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n" +
+			"Sec-WebSocket-Accept: " + wsAccept(r.Header.Get("Sec-WebSocket-Key")) + "\r\n\r\n")
+		brw.Flush()
+		ws := &wsConn{conn: conn, br: brw.Reader}
+
+		_, msg, err := ws.ReadMessage()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		var sub map[string][]string
+		json.Unmarshal(msg, &sub)
+		subs <- sub["subscribe"]
+		for _, sym := range sub["subscribe"] {
+			var b []byte
+			b = appendProtoString(b, 1, sym)
+			b = appendProtoFloat(b, 2, 101.5)
+			b = appendProtoSint(b, 3, 1700000000000)
+			b = appendProtoTag(b, 7, 0)
+			b = binary.AppendUvarint(b, uint64(RegularMarket))
+			b = appendProtoSint(b, 9, 123456)
+			ws.WriteText([]byte(base64.StdEncoding.EncodeToString(b)))
+		}
+		time.Sleep(20 * time.Millisecond)
+	}))
+}
+
+func TestStreamer(t *testing.T) {
+	subs := make(chan []string, 4)
+	srv := newStreamerStandIn(t, subs)
+	defer srv.Close()
+
+	s := NewStreamer()
+	s.URL = "ws" + strings.TrimPrefix(srv.URL, "http")
+	s.MinBackoff = 10 * time.Millisecond
+	s.MaxBackoff = 20 * time.Millisecond
+	s.Subscribe("AAPL")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go s.Run(ctx)
+
+	if sub := <-subs; strings.Join(sub, ",") != "AAPL" {
+		t.Errorf("unexpected first subscription %v", sub)
+	}
+	tick := <-s.Ticks()
+	if tick.Symbol != "AAPL" || tick.Price != 101.5 || tick.Time.UnixMilli() != 1700000000000 ||
+		tick.MarketHours.MarketState() != MarketRegular || tick.DayVolume != 123456 {
+		t.Errorf("unexpected tick %+v", tick)
+	}
+
+	// symbols added later are resubscribed along with the original ones after reconnecting
+	s.Subscribe("MSFT")
+	for sub := range subs {
+		if strings.Join(sub, ",") == "AAPL,MSFT" {
+			break
+		}
+	}
+	cancel()
+	for range s.Ticks() {
+	}
+	if err := s.Run(context.Background()); !errors.Is(err, ErrStreamerClosed) {
+		t.Errorf("expected ErrStreamerClosed from a second Run, got %v", err)
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	responses := []string{
+		`{"quoteResponse": {"result": [{"symbol": "AAPL", "regularMarketPrice": 100, "marketState": "REGULAR"}, {"symbol": "MSFT", "regularMarketPrice": 300, "marketState": "REGULAR"}]}}`,
+		`{"quoteResponse": {"result": [{"symbol": "AAPL", "regularMarketPrice": 101, "bid": 100.9, "marketState": "REGULAR"}, {"symbol": "MSFT", "regularMarketPrice": 300, "marketState": "REGULAR"}]}}`,
+		`{"quoteResponse": {"result": [{"symbol": "AAPL", "regularMarketPrice": 101, "bid": 100.9, "marketState": "POST"}, {"symbol": "MSFT", "regularMarketPrice": 300, "marketState": "CLOSED"}]}}`,
+	}
+	n := 0
+	c := newTestClient(func(req *http.Request) (*http.Response, error) {
+		resp := jsonResponse(http.StatusOK, responses[n])
+		n++
+		return resp, nil
+	})
+	w := NewWatcher(&c, []string{"AAPL", "MSFT"}, time.Second)
+	ch, cancel := w.Subscribe(10, "AAPL")
+	defer cancel()
+	var all []QuoteEvent
+	w.OnChange(func(ev QuoteEvent) { all = append(all, ev) })
+
+	ctx := context.Background()
+	for i := 0; i < len(responses); i++ {
+		trading, err := w.Poll(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !trading {
+			t.Errorf("poll %d: expected a market to be trading", i)
+		}
+	}
+	if len(ch) != 2 || len(all) != 3 {
+		t.Fatalf("expected 2 AAPL events and 3 events in total, got %d and %d", len(ch), len(all))
+	}
+	ev := <-ch
+	if !ev.Changes.Has(PriceChanged|BidAskChanged) || ev.Changes.Has(VolumeChanged) || ev.Previous.RegularMarketPrice != 100 {
+		t.Errorf("unexpected first event %+v", ev)
+	}
+	ev = <-ch
+	if ev.Changes != MarketStateChanged || ev.Current.MarketState != MarketPost {
+		t.Errorf("unexpected second event %+v", ev)
+	}
+
+	// a poll blocked on an unbuffered subscriber returns once the subscription is cancelled
+	responses = append(responses, `{"quoteResponse": {"result": [{"symbol": "AAPL", "regularMarketPrice": 102, "marketState": "POST"}]}}`)
+	blocked, cancelBlocked := w.Subscribe(0, "AAPL")
+	done := make(chan error)
+	go func() {
+		_, err := w.Poll(ctx)
+		done <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cancelBlocked()
+	cancelBlocked()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Error(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Poll blocked on a cancelled subscription")
+	}
+	// cancelling closes the channel
+	select {
+	case _, ok := <-blocked:
+		if ok {
+			t.Error("unexpected event on a cancelled subscription")
+		}
+	case <-time.After(time.Second):
+		t.Error("cancelling did not close the channel")
+	}
+
+	// symbols are case-insensitive
+	responses = append(responses,
+		`{"quoteResponse": {"result": [{"symbol": "IBM", "regularMarketPrice": 140, "marketState": "REGULAR"}]}}`,
+		`{"quoteResponse": {"result": [{"symbol": "IBM", "regularMarketPrice": 141, "marketState": "REGULAR"}]}}`)
+	lower := NewWatcher(&c, []string{"ibm"}, time.Second)
+	lowerCh, cancelLower := lower.Subscribe(10, "ibm")
+	defer cancelLower()
+	for i := 0; i < 2; i++ {
+		if _, err := lower.Poll(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(lowerCh) != 1 {
+		t.Fatalf("expected 1 event for a lowercase symbol, got %d", len(lowerCh))
+	}
+	if ev := <-lowerCh; ev.Symbol != "IBM" || ev.Current.RegularMarketPrice != 141 {
+		t.Errorf("unexpected event %+v", ev)
+	}
+
+	// Run never polls more than once a second, and backs off after errors
+	w.Interval = 0
+	for _, tc := range []struct {
+		trading  bool
+		failures int
+		want     time.Duration
+	}{
+		{true, 0, time.Second},
+		{false, 0, 5 * time.Minute},
+		{true, 1, 2 * time.Second},
+		{true, 3, 8 * time.Second},
+		{false, 20, 5 * time.Minute},
+	} {
+		if got := w.nextWait(tc.trading, tc.failures); got != tc.want {
+			t.Errorf("nextWait(%v, %d) = %v, want %v", tc.trading, tc.failures, got, tc.want)
+		}
+	}
+}
+
+func TestAlertEngine(t *testing.T) {
+	dir := t.TempDir()
+	statePath := dir + "/alerts.json"
+	var got []AlertEvent
+	rec := NotifierFunc(func(ev AlertEvent) error { got = append(got, ev); return nil })
+
+	var hooks atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var ev AlertEvent
+		if err := json.NewDecoder(r.Body).Decode(&ev); err != nil || ev.Alert.Symbol != "AAPL" {
+			t.Errorf("unexpected webhook body: %v %+v", err, ev)
+		}
+		hooks.Add(1)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	e, err := NewAlertEngine(statePath, rec, WebhookNotifier{URL: srv.URL}, FileNotifier{Path: dir + "/events.ndjson"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = e.Add(Alert{Symbol: "AAPL", Kind: "bogus"}); !errors.Is(err, ErrAlertKind) {
+		t.Errorf("expected ErrAlertKind, got %v", err)
+	}
+	err = e.Add(
+		Alert{Id: "cross", Symbol: "AAPL", Kind: PriceAbove, Level: 150, Cooldown: time.Hour},
+		Alert{Id: "spike", Symbol: "AAPL", Kind: VolumeSpike, Level: 2},
+		Alert{Id: "high", Symbol: "AAPL", Kind: NewFiftyTwoWeekHigh},
+		Alert{Id: "move", Symbol: "MSFT", Kind: PercentChange, Level: 5},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t0 := time.Date(2023, 10, 2, 14, 0, 0, 0, time.UTC)
+	q := Quote{Symbol: "AAPL", RegularMarketPrice: 149, FiftyTwoWeekHigh: 160, RegularMarketVolume: 100, AverageDailyVolume10Day: 100}
+	if evs := e.Check(q, t0); len(evs) != 0 {
+		t.Fatalf("expected no events, got %+v", evs)
+	}
+	q.RegularMarketPrice, q.RegularMarketVolume = 151, 250
+	evs := e.Check(q, t0.Add(time.Minute))
+	if len(evs) != 2 || evs[0].Alert.Id != "cross" || evs[1].Alert.Id != "spike" || evs[1].Value != 2.5 {
+		t.Fatalf("unexpected events %+v", evs)
+	}
+	// conditions that stay true are not reported again
+	if evs := e.Check(q, t0.Add(2*time.Minute)); len(evs) != 0 {
+		t.Errorf("expected duplicates to be suppressed, got %+v", evs)
+	}
+	// re-armed, but within the cooldown
+	q.RegularMarketPrice = 149
+	e.Check(q, t0.Add(3*time.Minute))
+	q.RegularMarketPrice = 152
+	if evs := e.Check(q, t0.Add(4*time.Minute)); len(evs) != 0 {
+		t.Errorf("expected cooldown to suppress event, got %+v", evs)
+	}
+
+	// state survives a restart
+	e, err = NewAlertEngine(statePath, rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(e.Alerts()); n != 4 {
+		t.Fatalf("expected 4 restored alerts, got %d", n)
+	}
+	q.RegularMarketPrice = 165
+	evs = e.Check(q, t0.Add(2*time.Hour))
+	if len(evs) != 2 || evs[0].Alert.Id != "cross" || evs[1].Alert.Id != "high" {
+		t.Fatalf("unexpected events after restart %+v", evs)
+	}
+	if evs := e.Check(Quote{Symbol: "MSFT", RegularMarketChangePercent: -6.1}, t0); len(evs) != 1 || evs[0].Message != "MSFT changed -6.10%" {
+		t.Errorf("unexpected percent change events %+v", evs)
+	}
+
+	if len(got) != 5 || hooks.Load() != 2 {
+		t.Errorf("expected 5 notifications and 2 webhooks, got %d and %d", len(got), hooks.Load())
+	}
+	b, err := os.ReadFile(dir + "/events.ndjson")
+	if err != nil || strings.Count(string(b), "\n") != 2 {
+		t.Errorf("expected 2 events in file, got %q (%v)", b, err)
+	}
+
+	// conditions that already hold on the first poll are reported
+	c := newTestClient(func(req *http.Request) (*http.Response, error) {
+		return jsonResponse(http.StatusOK, `{"quoteResponse": {"result": [{"symbol": "AAPL", "regularMarketPrice": 160, "fiftyTwoWeekHigh": 170, "averageDailyVolume10Day": 1000, "marketState": "REGULAR"}]}}`), nil
+	})
+	e, _ = NewAlertEngine("")
+	e.Add(Alert{Id: "above", Symbol: "AAPL", Kind: PriceAbove, Level: 150}, Alert{Id: "high", Symbol: "AAPL", Kind: NewFiftyTwoWeekHigh},
+		Alert{Id: "spike", Symbol: "AAPL", Kind: VolumeSpike, Level: 2})
+	w := NewWatcher(&c, []string{"AAPL"}, time.Second)
+	stop := e.Watch(w)
+	defer stop()
+	if _, err = w.Poll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if st := e.state["above"]; st == nil || !st.Active {
+		t.Errorf("expected the baseline quote to fire the alert, got %+v", st)
+	}
+
+	// ticks are merged onto the last checked quote
+	if evs := e.CheckTick(Tick{Symbol: "AAPL", Price: 171, MarketHours: RegularMarket, DayVolume: 2500}); len(evs) != 2 ||
+		evs[0].Alert.Id != "high" || evs[1].Alert.Id != "spike" || evs[0].Quote.FiftyTwoWeekHigh != 170 {
+		t.Errorf("unexpected tick events %+v", evs)
+	}
+	if evs := e.CheckTick(Tick{Symbol: "AAPL", Price: 140, MarketHours: PostMarket}); len(evs) != 0 {
+		t.Errorf("post-market ticks should not change the regular market price, got %+v", evs)
+	}
+	s := NewStreamer()
+	s.ticks <- Tick{Symbol: "AAPL", Price: 140, MarketHours: RegularMarket}
+	s.ticks <- Tick{Symbol: "AAPL", Price: 151, MarketHours: RegularMarket}
+	close(s.ticks)
+	n := len(got)
+	e.Notifiers = []Notifier{rec}
+	e.WatchStreamer(s)
+	if len(got) != n+1 || got[n].Alert.Id != "above" {
+		t.Errorf("expected the streamed price to fire the alert again, got %+v", got[n:])
+	}
+}
+
+// newHistoryServer returns a test Client whose download endpoint serves the daily bars of closes,
+// starting on 2023-10-02, that fall within the requested period. The adjusted close of the i-th bar
+// is its close multiplied by adj(i), or the close itself if adj is nil. The number of requests is counted in n.
+func newHistoryServer(closes []float64, adj func(int) float64, n *atomic.Int32) Client {
+	return newTestClient(func(req *http.Request) (*http.Response, error) {
+		n.Add(1)
+		q := req.URL.Query()
+		p1, _ := strconv.ParseInt(q.Get("period1"), 10, 64)
+		p2, _ := strconv.ParseInt(q.Get("period2"), 10, 64)
+		var sb strings.Builder
+		sb.WriteString("Date,Open,High,Low,Close,Adj Close,Volume\n")
+		day := time.Date(2023, 10, 2, 0, 0, 0, 0, time.UTC)
+		for i, c := range closes {
+			d := day.AddDate(0, 0, i)
+			if d.Unix() < p1 || d.Unix() >= p2 {
+				continue
+			}
+			f := strconv.FormatFloat(c, 'f', -1, 64)
+			a := f
+			if adj != nil {
+				a = strconv.FormatFloat(c*adj(i), 'f', 6, 64)
+			}
+			sb.WriteString(d.Format("2006-01-02") + "," + f + "," + f + "," + f + "," + f + "," + a + ",100\n")
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(sb.String()))}, nil
+	})
+}
+
+func TestBarStore(t *testing.T) {
+	var n atomic.Int32
+	closes := []float64{10, 11, 12, 13, 14, 15, 16, 17, 18, 19}
+	c := newHistoryServer(closes, nil, &n)
+	s, err := OpenBarStore(t.TempDir(), &c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	day := func(i int) time.Time { return time.Date(2023, 10, 2+i, 0, 0, 0, 0, time.UTC) }
+
+	if _, err := s.Load("AAPL", OneDay, day(0), day(10)); !errors.Is(err, ErrNotStored) {
+		t.Errorf("expected ErrNotStored, got %v", err)
+	}
+	added, err := s.Update("AAPL", OneDay, day(3), day(6))
+	if err != nil || added != 3 {
+		t.Fatalf("expected 3 bars, got %d (%v)", added, err)
+	}
+	// head and tail are fetched separately; the last stored bar is requested again
+	added, err = s.Update("AAPL", OneDay, day(1), day(8))
+	if err != nil || added != 4 || n.Load() != 3 {
+		t.Fatalf("expected 4 bars in 3 requests, got %d in %d (%v)", added, n.Load(), err)
+	}
+	// nothing is requested for a range that is already covered
+	if added, err = s.Update("AAPL", OneDay, day(2), day(7)); err != nil || added != 0 || n.Load() != 3 {
+		t.Errorf("expected no requests, got %d bars in %d (%v)", added, n.Load(), err)
+	}
+
+	tk, err := s.Load("AAPL", OneDay, day(2), day(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tk.HistoricClose) != 3 || tk.HistoricClose[0] != 12 || tk.HistoricDates[2] != day(4).Unix() || tk.Interval != OneDay {
+		t.Errorf("unexpected range %+v", tk)
+	}
+	from, to, err := s.Coverage("AAPL", OneDay)
+	if err != nil || !from.Equal(day(1)) || !to.Equal(day(8)) {
+		t.Errorf("unexpected coverage %v %v %v", from, to, err)
+	}
+
+	res := s.UpdateAll([]string{"AAPL", "MSFT"}, OneDay, day(0), day(10))
+	if res[0].Err != nil || res[0].Added != 3 || res[1].Err != nil || res[1].Added != 10 {
+		t.Errorf("unexpected UpdateAll results %+v", res)
+	}
+	if _, err = s.Update("AAPL", "1x", day(0), day(1)); !errors.Is(err, ErrInterval) {
+		t.Errorf("expected ErrInterval, got %v", err)
+	}
+}
+
+func TestBarStoreRevisions(t *testing.T) {
+	closes := []float64{10, 11, 12, 13, 14, 15, 16, 17, 18, 19}
+	day := func(i int) time.Time { return time.Date(2023, 10, 2+i, 0, 0, 0, 0, time.UTC) }
+	// a dividend goes ex on day 6, once the first update has been made
+	exDiv := false
+	adj := func(i int) float64 {
+		if exDiv && i < 6 {
+			return 0.98
+		}
+		return 1
+	}
+
+	for _, refetch := range []bool{false, true} {
+		var n atomic.Int32
+		exDiv = false
+		c := newHistoryServer(closes, adj, &n)
+		s, err := OpenBarStore(t.TempDir(), &c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.RefetchOnRevision = refetch
+		var events []AdjustmentEvent
+		s.OnAdjustment = func(ev AdjustmentEvent) { events = append(events, ev) }
+
+		if _, err = s.Update("AAPL", OneDay, day(0), day(5)); err != nil {
+			t.Fatal(err)
+		}
+		exDiv = true
+		if _, err = s.Update("AAPL", OneDay, day(0), day(10)); err != nil {
+			t.Fatal(err)
+		}
+		if len(events) != 1 || math.Abs(events[0].Factor-0.98) > 1e-9 || events[0].BarTime != day(4).Unix() || events[0].Refetched != refetch {
+			t.Fatalf("refetch=%v: unexpected events %+v", refetch, events)
+		}
+		want := int32(2)
+		if refetch {
+			want = 3
+		}
+		if n.Load() != want {
+			t.Errorf("refetch=%v: expected %d requests, got %d", refetch, want, n.Load())
+		}
+		tk, err := s.Load("AAPL", OneDay, day(0), day(10))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, a := range tk.HistoricAdjClose {
+			if want := closes[i] * adj(i); math.Abs(a-want) > 1e-6 {
+				t.Errorf("refetch=%v: bar %d: expected adjusted close %v, got %v", refetch, i, want, a)
+			}
+		}
+		stored, err := s.Adjustments("AAPL", OneDay)
+		if err != nil || len(stored) != 1 {
+			t.Errorf("expected the adjustment to be recorded, got %+v (%v)", stored, err)
+		}
+		// an unchanged basis is not reported again
+		if _, err = s.Update("AAPL", OneDay, day(0), day(11)); err != nil || len(events) != 1 {
+			t.Errorf("unexpected events %+v (%v)", events, err)
+		}
+	}
+}
+
+func TestCache(t *testing.T) {
+	var requests atomic.Int32
+	var lastURL atomic.Value
+	release := make(chan struct{})
+	c := newTestClient(func(req *http.Request) (*http.Response, error) {
+		requests.Add(1)
+		lastURL.Store(req.URL.String())
+		if strings.Contains(req.URL.Path, "quoteSummary") {
+			<-release
+			return jsonResponse(http.StatusOK, `{"quoteSummary": {"result": [{"price": {"symbol": "AAPL"}}], "error": null}}`), nil
+		}
+		var quotes []string
+		for _, s := range strings.Split(req.URL.Query().Get("symbols"), ",") {
+			if s != "BOGUS" {
+				quotes = append(quotes, `{"symbol": "`+strings.ToUpper(s)+`", "regularMarketPrice": 1}`)
+			}
+		}
+		return jsonResponse(http.StatusOK, `{"quoteResponse": {"result": [`+strings.Join(quotes, ",")+`]}}`), nil
+	})
+	now := time.Date(2023, 10, 2, 14, 0, 0, 0, time.UTC)
+	c.Cache = NewCache(time.Minute, time.Hour)
+	c.Cache.now = func() time.Time { return now }
+
+	// concurrent identical summary requests are coalesced
+	const n = 5
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		go func() {
+			summary, err := c.GetQuoteSummary("AAPL", []QuoteParam{Price})
+			if err == nil && !summary.Has("price.symbol") {
+				err = errors.New("missing price module")
+			}
+			errs <- err
+		}()
+	}
+	for c.Cache.Stats().Coalesced+c.Cache.Stats().Misses < n {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	for i := 0; i < n; i++ {
+		if err := <-errs; err != nil {
+			t.Error(err)
+		}
+	}
+	if _, err := c.GetQuoteSummary("AAPL", []QuoteParam{Price}); err != nil || requests.Load() != 1 {
+		t.Errorf("expected a single request, got %d (%v)", requests.Load(), err)
+	}
+
+	// quotes are cached per symbol, including the symbols Yahoo ignores
+	requests.Store(0)
+	if q, err := c.GetQuotes([]string{"AAPL", "MSFT", "BOGUS"}); err != nil || len(q) != 2 {
+		t.Fatalf("unexpected quotes %v (%v)", q, err)
+	}
+	q, err := c.GetQuotes([]string{"MSFT", "GOOG", "BOGUS"})
+	if err != nil || len(q) != 2 || q["GOOG"].RegularMarketPrice != 1 {
+		t.Fatalf("unexpected quotes %v (%v)", q, err)
+	}
+	if requests.Load() != 2 || !strings.HasSuffix(lastURL.Load().(string), "symbols=GOOG") {
+		t.Errorf("expected only GOOG to be requested, got %d requests, last %v", requests.Load(), lastURL.Load())
+	}
+	now = now.Add(2 * time.Minute)
+	c.GetQuotes([]string{"MSFT"})
+	if requests.Load() != 3 {
+		t.Errorf("expected expired quote to be requested again")
+	}
+
+	s := c.Cache.Stats()
+	if s.Hits != 3 || s.Coalesced != n-1 || s.Misses != 6 || s.Expired != 1 || s.Entries != 5 {
+		t.Errorf("unexpected stats %+v", s)
+	}
+	c.Cache.Prune()
+	if s = c.Cache.Stats(); s.Entries != 2 {
+		t.Errorf("expected expired quotes to be pruned, got %+v", s)
+	}
+
+	// results are keyed by the symbols Yahoo returns, and symbols are cached case-insensitively
+	requests.Store(0)
+	q, err = c.GetQuotes([]string{"msft", "nvda"})
+	if _, ok := q["NVDA"]; err != nil || len(q) != 2 || !ok || q["MSFT"].RegularMarketPrice != 1 {
+		t.Fatalf("unexpected quotes %v (%v)", q, err)
+	}
+	if q, err = c.GetQuotes([]string{"NVDA"}); err != nil || len(q) != 1 || requests.Load() != 1 {
+		t.Errorf("expected NVDA to be cached, got %v after %d requests (%v)", q, requests.Load(), err)
+	}
+
+	// a Cache created without NewCache works as well
+	c.Cache = &Cache{QuoteTTL: time.Minute}
+	requests.Store(0)
+	for i := 0; i < 2; i++ {
+		if q, err = c.GetQuotes([]string{"AAPL"}); err != nil || len(q) != 1 {
+			t.Fatalf("unexpected quotes %v (%v)", q, err)
+		}
+	}
+	if requests.Load() != 1 || c.Cache.Stats().Hits != 1 {
+		t.Errorf("expected 1 request and 1 hit, got %d and %+v", requests.Load(), c.Cache.Stats())
+	}
+
+	// requests coalesced with one that panics return an error instead of blocking
+	var k *Cache
+	pc := newTestClient(func(req *http.Request) (*http.Response, error) {
+		for k.Stats().Coalesced == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		panic("transport failure")
+	})
+	for _, get := range []func() error{
+		func() error { _, err := pc.GetQuotes([]string{"AAPL"}); return err },
+		func() error { _, err := pc.GetQuoteSummary("AAPL", []QuoteParam{Price}); return err },
+	} {
+		k = NewCache(time.Minute, time.Minute)
+		pc.Cache = k
+		panicked := make(chan any)
+		go func() {
+			defer func() { panicked <- recover() }()
+			get()
+		}()
+		for k.Stats().Misses == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		if err := get(); !errors.Is(err, errRequestPanicked) {
+			t.Errorf("expected errRequestPanicked, got %v", err)
+		}
+		if p := <-panicked; p == nil {
+			t.Error("expected the first request to panic")
+		}
+	}
+}
+
+func TestTickerReadWrite(t *testing.T) {
+	dir := t.TempDir()
+	want := Ticker{
+		Symbol:           "AAPL",
+		Interval:         OneDay,
+		HistoricDates:    make([]int64, 500),
+		HistoricOpen:     make([]float64, 500),
+		HistoricHigh:     make([]float64, 500),
+		HistoricLow:      make([]float64, 500),
+		HistoricClose:    make([]float64, 500),
+		HistoricAdjClose: make([]float64, 500),
+		HistoricVolume:   make([]int, 500),
+	}
+	for i := range want.HistoricDates {
+		want.HistoricDates[i] = 1696204800 + int64(i)*86400
+		want.HistoricOpen[i] = 170.1 + float64(i)/3
+		want.HistoricHigh[i] = 171.123456789 + float64(i)
+		want.HistoricLow[i] = 169.9
+		want.HistoricClose[i] = 1.0 / float64(i+3)
+		want.HistoricAdjClose[i] = math.Pi * float64(i)
+		want.HistoricVolume[i] = 1000000 + i
+	}
+	if err := want.ToCsv(dir + "/aapl.csv"); err != nil {
+		t.Fatal(err)
+	}
+	if err := want.ToJson(dir + "/aapl.json"); err != nil {
+		t.Fatal(err)
+	}
+	fromCsv := Ticker{Symbol: "AAPL", Interval: OneDay}
+	if err := fromCsv.FromCsv(dir + "/aapl.csv"); err != nil {
+		t.Fatal(err)
+	}
+	var fromJson Ticker
+	if err := fromJson.FromJson(dir + "/aapl.json"); err != nil {
+		t.Fatal(err)
+	}
+	wb, _ := json.Marshal(want)
+	for name, got := range map[string]Ticker{"csv": fromCsv, "json": fromJson} {
+		if gb, _ := json.Marshal(got); string(gb) != string(wb) {
+			t.Errorf("%s round trip differs", name)
+		}
+	}
+
+	yahoo := "Date,Open,High,Low,Close,Adj Close,Volume\n" +
+		"2023-10-02,171.22,174.30,170.93,173.75,173.04,52164500\n" +
+		"2023-10-03,null,null,null,null,null,null\n" +
+		"2023-10-04,171.09,174.21,170.97,173.66,172.95,53020300\n"
+	var tk Ticker
+	if err := tk.ReadCSV(strings.NewReader(yahoo)); err != nil {
+		t.Fatal(err)
+	}
+	if len(tk.HistoricDates) != 2 || tk.HistoricDates[1] != 1696377600 || tk.HistoricAdjClose[0] != 173.04 {
+		t.Errorf("unexpected ticker %+v", tk)
+	}
+
+	var pe *csv.ParseError
+	err := tk.ReadCSV(strings.NewReader("Date,Open,High,Low,Close,Volume\n1,2,3,4,5,6\n2,2,3,4,5\n"))
+	if !errors.As(err, &pe) || pe.Line != 3 || !errors.Is(err, csv.ErrFieldCount) {
+		t.Errorf("expected field count error on line 3, got %v", err)
+	}
+	err = tk.ReadCSV(strings.NewReader("Date,Open,High,Low,Close,Volume\n1,2,3,4,5,6\n2,x,3,4,5,6\n"))
+	if !errors.As(err, &pe) || pe.Line != 3 || !errors.Is(err, ErrMalformedData) {
+		t.Errorf("expected malformed data on line 3, got %v", err)
+	}
+	err = tk.ReadJSON(strings.NewReader(`{"HistoricDates": [1, 2], "HistoricOpen": [1]}`))
+	if !errors.Is(err, ErrColumnLength) {
+		t.Errorf("expected ErrColumnLength, got %v", err)
+	}
+}
+
+func TestExport(t *testing.T) {
+	a := TickerFromBars("AAPL", OneDay, []Bar{
+		{Time: 1696267800, Open: 171.22, High: 174.3, Low: 170.93, Close: 173.75, AdjClose: 173.041234, Volume: 52164500},
+		{Time: 1696354200, Open: 172.26, High: 173.63, Low: 170.82, Close: 172.4, AdjClose: math.NaN(), Volume: 49594600},
+	})
+	b := TickerFromBars("MSFT", OneDay, []Bar{{Time: 1696267800, Close: 321.8, AdjClose: 320.1, Volume: 20570000}})
+
+	var sb strings.Builder
+	if err := a.WriteCSV(&sb, DefaultExportOptions()); err != nil {
+		t.Fatal(err)
+	}
+	var back Ticker
+	if err := back.ReadCSV(strings.NewReader(sb.String())); err != nil || len(back.HistoricDates) != 1 || back.HistoricAdjClose[0] != 173.041234 {
+		t.Errorf("expected the row with a null to be skipped, got %+v (%v)", back, err)
+	}
+	sb.Reset()
+	if err := a.WriteCSV(&sb, ExportOptions{}); err != nil || !strings.Contains(sb.String(), ",173.75,173.041234,") {
+		t.Errorf("expected the zero ExportOptions to preserve prices, got %q (%v)", sb.String(), err)
+	}
+
+	// JSON written with the default options reads back, including the symbol and interval if they are exported
+	for _, opts := range []ExportOptions{{}, {Columns: append([]Column{ColSymbol, ColInterval}, DefaultColumns...), DateFormat: RFC3339Date}} {
+		sb.Reset()
+		if err := WriteTickersJSON(&sb, []Ticker{a}, opts); err != nil {
+			t.Fatal(err)
+		}
+		back = Ticker{}
+		if err := back.ReadJSON(strings.NewReader(sb.String())); err != nil {
+			t.Fatalf("reading %s: %v", sb.String(), err)
+		}
+		if !math.IsNaN(back.HistoricAdjClose[1]) {
+			t.Errorf("expected a NaN adjusted close, got %v", back.HistoricAdjClose[1])
+		}
+		want := a
+		if len(opts.Columns) == 0 {
+			want.Symbol, want.Interval = "", ""
+		}
+		back.HistoricAdjClose[1], want.HistoricAdjClose = 0, []float64{a.HistoricAdjClose[0], 0}
+		if !reflect.DeepEqual(back, want) {
+			t.Errorf("JSON round trip differs:\n%+v\n%+v", back, want)
+		}
+	}
+	if err := back.ReadJSON(strings.NewReader(`{"Symbol":["A","B"],"Date":[1,2],"Open":[1,1],"High":[1,1],"Low":[1,1],"Close":[1,1],"Volume":[1,1]}`)); !errors.Is(err, ErrMalformedData) {
+		t.Errorf("expected ErrMalformedData for mixed symbols, got %v", err)
+	}
+
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+	opts := ExportOptions{
+		DateFormat: LocalDate,
+		Location:   ny,
+		Columns:    []Column{ColSymbol, ColDate, ColAdjClose, ColVolume},
+		Headers:    map[Column]string{ColAdjClose: "adj_close", ColDate: "date"},
+	}
+	opts.FixedPrecision, opts.Precision = true, 2
+	sb.Reset()
+	if err := WriteTickersCSV(&sb, []Ticker{a, b}, opts); err != nil {
+		t.Fatal(err)
+	}
+	want := "Symbol,date,adj_close,Volume\nAAPL,2023-10-02,173.04,52164500\nAAPL,2023-10-03,null,49594600\nMSFT,2023-10-02,320.10,20570000\n"
+	if sb.String() != want {
+		t.Errorf("unexpected CSV\n%s", sb.String())
+	}
+
+	opts.DateFormat = RFC3339Date
+	for layout, want := range map[JSONLayout]string{
+		ColumnJSON: `{"Symbol":["AAPL","AAPL","MSFT"],"date":["2023-10-02T13:30:00-04:00","2023-10-03T13:30:00-04:00","2023-10-02T13:30:00-04:00"],"adj_close":[173.04,null,320.10],"Volume":[52164500,49594600,20570000]}` + "\n",
+		RowJSON:    `[{"Symbol":"AAPL","date":"2023-10-02T13:30:00-04:00","adj_close":173.04,"Volume":52164500},{"Symbol":"AAPL","date":"2023-10-03T13:30:00-04:00","adj_close":null,"Volume":49594600},{"Symbol":"MSFT","date":"2023-10-02T13:30:00-04:00","adj_close":320.10,"Volume":20570000}]` + "\n",
+		NDJSON:     `{"Symbol":"AAPL","date":"2023-10-02T13:30:00-04:00","adj_close":173.04,"Volume":52164500}` + "\n" + `{"Symbol":"AAPL","date":"2023-10-03T13:30:00-04:00","adj_close":null,"Volume":49594600}` + "\n" + `{"Symbol":"MSFT","date":"2023-10-02T13:30:00-04:00","adj_close":320.10,"Volume":20570000}` + "\n",
+	} {
+		opts.JSON = layout
+		sb.Reset()
+		if err := WriteTickersJSON(&sb, []Ticker{a, b}, opts); err != nil {
+			t.Fatal(err)
+		}
+		if sb.String() != want {
+			t.Errorf("layout %d: unexpected JSON\n%s", layout, sb.String())
+		}
+		if layout != NDJSON && !json.Valid([]byte(sb.String())) {
+			t.Errorf("layout %d: invalid JSON", layout)
+		}
+	}
+
+	opts.Columns = []Column{"Dividends"}
+	if err := a.WriteJSON(io.Discard, opts); !errors.Is(err, ErrColumn) {
+		t.Errorf("expected ErrColumn, got %v", err)
+	}
+}
+
+func TestParquet(t *testing.T) {
+	a := TickerFromBars("AAPL", OneDay, []Bar{
+		{Time: 1696267800, Open: 171.22, High: 174.3, Low: 170.93, Close: 173.75, AdjClose: 173.041234, Volume: 52164500},
+		{Time: 1696354200, Open: 172.26, High: 173.63, Low: 170.82, Close: 172.4, AdjClose: 171.7, Volume: 49594600},
+	})
+	b := TickerFromBars("MSFT", OneWeek, make([]Bar, 40))
+	for i := range b.HistoricDates {
+		b.HistoricDates[i] = -86400 * int64(i)
+		b.HistoricClose[i] = float64(i) / 7
+	}
+	var buf bytes.Buffer
+	if err := WriteTickersParquet(&buf, []Ticker{a, b}); err != nil {
+		t.Fatal(err)
+	}
+	tickers, err := ReadTickersParquet(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wb, _ := json.Marshal([]Ticker{a, b})
+	if gb, _ := json.Marshal(tickers); string(gb) != string(wb) {
+		t.Errorf("round trip differs:\n%s\n%s", gb, wb)
+	}
+
+	quotes := []Quote{
+		{Symbol: "AAPL", RegularMarketPrice: 173.75, MarketState: MarketRegular, RegularMarketTime: 1696276800, Tradeable: true, MarketCap: 2700000000000},
+		{Symbol: "BTC-USD", QuoteType: "CRYPTOCURRENCY", CryptoTradeable: true, FirstTradeDateMilliseconds: 1410912000000},
+	}
+	buf.Reset()
+	if err := WriteQuotesParquet(&buf, quotes); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadQuotesParquet(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0] != quotes[0] || got[1] != quotes[1] {
+		t.Errorf("unexpected quotes %+v", got)
+	}
+
+	// the quote file has no bar columns
+	if _, err = ReadTickersParquet(bytes.NewReader(buf.Bytes()), int64(buf.Len())); !errors.Is(err, ErrParquet) {
+		t.Errorf("expected ErrParquet, got %v", err)
+	}
+	data := buf.Bytes()
+	for _, n := range []int{0, 11, len(data) / 2, len(data) - 1} {
+		if _, err = ReadQuotesParquet(bytes.NewReader(data[:n]), int64(n)); err == nil {
+			t.Errorf("expected an error reading %d bytes", n)
+		}
+	}
+}
+
+// The fixtures were written by DuckDB 1.1.3 with COPY bars TO '...' (FORMAT PARQUET), which uses Snappy and
+// dictionary encoding by default, from a table of 5 daily bars per symbol starting at TIMESTAMP
+// '2023-10-02 13:30:00', with open = base + d, close = open + 0.5, a null adj_close for d = 3 and an
+// INTEGER volume = 1000000 + 1000d.
+func TestParquetDuckDB(t *testing.T) {
+	for _, name := range []string{"duckdb_tickers.parquet", "duckdb_tickers_gzip.parquet"} {
+		f, err := os.Open("testdata/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fi, _ := f.Stat()
+		tickers, err := ReadTickersParquet(f, fi.Size())
+		f.Close()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if len(tickers) != 2 {
+			t.Fatalf("%s: expected 2 tickers, got %d", name, len(tickers))
+		}
+		for i, want := range []struct {
+			symbol string
+			base   float64
+		}{{"AAPL", 170}, {"MSFT", 320}} {
+			tk := tickers[i]
+			bars := tk.Bars()
+			if tk.Symbol != want.symbol || tk.Interval != OneDay || len(bars) != 5 {
+				t.Fatalf("%s: unexpected ticker %s %s with %d bars", name, tk.Symbol, tk.Interval, len(bars))
+			}
+			for d, b := range bars {
+				open := want.base + float64(d)
+				if b.Time != 1696253400+86400*int64(d) || b.Open != open || b.High != open+1.5 || b.Low != open-1.25 ||
+					b.Close != open+0.5 || b.Volume != 1000000+1000*d {
+					t.Errorf("%s: unexpected %s bar %d: %+v", name, want.symbol, d, b)
+				}
+				if d == 3 != math.IsNaN(b.AdjClose) {
+					t.Errorf("%s: unexpected %s adj_close %v for bar %d", name, want.symbol, b.AdjClose, d)
+				}
+			}
+		}
+	}
+
+	// ZSTD compression is not supported
+	data, err := os.ReadFile("testdata/duckdb_tickers_zstd.parquet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = ReadTickersParquet(bytes.NewReader(data), int64(len(data))); !errors.Is(err, ErrParquet) {
+		t.Errorf("expected ErrParquet, got %v", err)
+	}
+}
+
+// fakeSQL is a database/sql driver that records the statements it runs and fails those containing failOn.
+// Queries return no rows, with the columns of the first table in columns whose quoted name they mention.
+type fakeSQL struct {
+	mu      sync.Mutex
+	log     []string // statements, BEGIN, COMMIT and ROLLBACK
+	args    [][]driver.Value
+	columns map[string][]string
+	failOn  string
+}
+
+func (d *fakeSQL) Connect(context.Context) (driver.Conn, error) { return fakeSQLConn{d}, nil }
+func (d *fakeSQL) Driver() driver.Driver                        { return d }
+func (d *fakeSQL) Open(string) (driver.Conn, error)             { return fakeSQLConn{d}, nil }
+
+func (d *fakeSQL) record(stmt string, args []driver.Value) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.log = append(d.log, stmt)
+	d.args = append(d.args, args)
+	if d.failOn != "" && strings.Contains(stmt, d.failOn) {
+		return errors.New("fake failure")
+	}
+	return nil
+}
+
+func (d *fakeSQL) reset(failOn string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.log, d.args, d.failOn = nil, nil, failOn
+}
+
+type fakeSQLConn struct{ d *fakeSQL }
+
+func (c fakeSQLConn) Prepare(q string) (driver.Stmt, error) { return fakeSQLStmt{c.d, q}, nil }
+func (c fakeSQLConn) Close() error                          { return nil }
+func (c fakeSQLConn) Begin() (driver.Tx, error) {
+	if err := c.d.record("BEGIN", nil); err != nil {
+		return nil, err
+	}
+	return fakeSQLTx{c.d}, nil
+}
+
+type fakeSQLTx struct{ d *fakeSQL }
+
+func (tx fakeSQLTx) Commit() error   { return tx.d.record("COMMIT", nil) }
+func (tx fakeSQLTx) Rollback() error { return tx.d.record("ROLLBACK", nil) }
+
+type fakeSQLStmt struct {
+	d *fakeSQL
+	q string
+}
+
+func (s fakeSQLStmt) Close() error  { return nil }
+func (s fakeSQLStmt) NumInput() int { return -1 }
+func (s fakeSQLStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return driver.RowsAffected(1), s.d.record(s.q, args)
+}
+func (s fakeSQLStmt) Query(args []driver.Value) (driver.Rows, error) {
+	if err := s.d.record(s.q, args); err != nil {
+		return nil, err
+	}
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	for table, cols := range s.d.columns {
+		if strings.Contains(s.q, `"`+table+`"`) {
+			return fakeSQLRows(cols), nil
+		}
+	}
+	return fakeSQLRows(nil), nil
+}
+
+type fakeSQLRows []string
+
+func (r fakeSQLRows) Columns() []string              { return r }
+func (r fakeSQLRows) Close() error                   { return nil }
+func (r fakeSQLRows) Next(dest []driver.Value) error { return io.EOF }
+
+// TestSQLiteSink checks the statements SQLiteSink sends to the database. internal/sqltest runs them against SQLite.
+func TestSQLiteSink(t *testing.T) {
+	fake := &fakeSQL{columns: map[string][]string{
+		"yfi_bars":   {"symbol", "interval", "time", "open", "high", "low", "close", "adj_close", "volume"},
+		"yfi_quotes": {"symbol", "regular_market_time"},
+	}}
+	db := sql.OpenDB(fake)
+	defer db.Close()
+	s := NewSQLiteSink(db)
+	ctx := context.Background()
+
+	if err := s.Migrate(ctx); err != nil {
+		t.Fatal(err)
+	}
+	contains := func(stmt string) bool {
+		for _, l := range fake.log {
+			if l == stmt {
+				return true
+			}
+		}
+		return false
+	}
+	for _, want := range []string{
+		`CREATE TABLE IF NOT EXISTS "yfi_bars" ("symbol" TEXT NOT NULL, "interval" TEXT NOT NULL, "time" BIGINT NOT NULL, ` +
+			`"open" DOUBLE PRECISION, "high" DOUBLE PRECISION, "low" DOUBLE PRECISION, "close" DOUBLE PRECISION, ` +
+			`"adj_close" DOUBLE PRECISION, "volume" BIGINT, PRIMARY KEY ("symbol", "interval", "time"))`,
+		`ALTER TABLE "yfi_quotes" ADD COLUMN "regular_market_price" DOUBLE PRECISION`,
+		`ALTER TABLE "yfi_quotes" ADD COLUMN "average_daily_volume_10_day" BIGINT`,
+		`ALTER TABLE "yfi_quotes" ADD COLUMN "tradeable" BOOLEAN`,
+		`ALTER TABLE "yfi_esg_scores" ADD COLUMN "total_esg" DOUBLE PRECISION`,
+	} {
+		if !contains(want) {
+			t.Errorf("missing statement %s", want)
+		}
+	}
+	for _, l := range fake.log {
+		if strings.HasPrefix(l, `ALTER TABLE "yfi_bars"`) || strings.HasPrefix(l, `ALTER TABLE "yfi_quotes" ADD COLUMN "symbol"`) {
+			t.Errorf("unexpected statement %s", l)
+		}
+	}
+
+	fake.reset("")
+	bars := []Bar{{Time: 1696267800, Open: 171.22, High: 174.3, Low: 170.93, Close: 173.75, AdjClose: 173.04, Volume: 52164500}}
+	if err := s.WriteTickers(ctx, TickerFromBars("AAPL", OneDay, bars)); err != nil {
+		t.Fatal(err)
+	}
+	upsert := `INSERT INTO "yfi_bars" ("symbol", "interval", "time", "open", "high", "low", "close", "adj_close", "volume") ` +
+		`VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?) ON CONFLICT ("symbol", "interval", "time") DO UPDATE SET ` +
+		`"open" = excluded."open", "high" = excluded."high", "low" = excluded."low", "close" = excluded."close", ` +
+		`"adj_close" = excluded."adj_close", "volume" = excluded."volume"`
+	if strings.Join(fake.log, "; ") != "BEGIN; "+upsert+"; COMMIT" {
+		t.Errorf("unexpected statements %q", fake.log)
+	}
+	wantArgs := []driver.Value{"AAPL", "1d", int64(1696267800), 171.22, 174.3, 170.93, 173.75, 173.04, int64(52164500)}
+	if len(fake.args) != 3 || !reflect.DeepEqual(fake.args[1], wantArgs) {
+		t.Errorf("unexpected arguments %v", fake.args)
+	}
+
+	// invalid Values are written as NULL
+	fake.reset("")
+	f := Fundamentals{Symbol: "AAPL", Series: map[string]FundamentalsSeries{
+		FundamentalsKey(Annual, TotalRevenue): {Freq: Annual, Item: TotalRevenue, Points: []FundamentalsPoint{
+			{AsOfDate: time.Date(2023, 9, 30, 0, 0, 0, 0, time.UTC), PeriodType: "12M", CurrencyCode: "USD"},
+		}},
+	}}
+	if err := s.WriteFundamentals(ctx, f); err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.args) != 3 || len(fake.args[1]) != 7 || fake.args[1][3] != "2023-09-30" || fake.args[1][6] != nil {
+		t.Errorf("unexpected arguments %v", fake.args)
+	}
+
+	// a failed write is rolled back
+	fake.reset(`INSERT INTO "yfi_bars"`)
+	if err := s.WriteTickers(ctx, TickerFromBars("AAPL", OneDay, bars)); err == nil {
+		t.Error("expected an error from a failed insert")
+	}
+	if n := len(fake.log); n == 0 || fake.log[n-1] != "ROLLBACK" || contains("COMMIT") {
+		t.Errorf("expected a rollback, got %q", fake.log)
+	}
+	fake.reset("BEGIN")
+	if err := s.WriteTickers(ctx, TickerFromBars("AAPL", OneDay, bars)); err == nil || len(fake.log) != 1 {
+		t.Errorf("expected only a failed BEGIN, got %q (%v)", fake.log, err)
+	}
+	fake.reset("")
+	if err := s.WriteTickers(ctx, Ticker{Symbol: "X", HistoricDates: []int64{1}}); !errors.Is(err, ErrColumnLength) || len(fake.log) != 0 {
+		t.Errorf("expected ErrColumnLength without statements, got %v after %q", err, fake.log)
+	}
+
+	// Migrate stops at the first failed statement
+	fake.reset(`CREATE TABLE IF NOT EXISTS "yfi_quotes"`)
+	if err := s.Migrate(ctx); err == nil {
+		t.Error("expected an error from a failed CREATE TABLE")
+	}
+	if n := len(fake.log); n == 0 || !strings.HasPrefix(fake.log[n-1], `CREATE TABLE IF NOT EXISTS "yfi_quotes"`) {
+		t.Errorf("unexpected statements after the failure %q", fake.log)
+	}
+}